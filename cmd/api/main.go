@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/0xBoji/web3-edu-core/config"
 	"github.com/0xBoji/web3-edu-core/internal/api"
 	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
 	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
 )
 
 func init() {
@@ -18,12 +24,42 @@ func init() {
 
 	// Setup Redis
 	redis.Setup()
+
+	// Load JWT signing keys
+	if err := utils.SetupKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Set up the encryption of TOTP secrets
+	if err := utils.SetupSecretCipher(); err != nil {
+		log.Fatalf("Failed to set up secret encryption: %v", err)
+	}
+
+	// Load the common password lists
+	if err := utils.SetupPasswordPolicy(); err != nil {
+		log.Fatalf("Failed to load common passwords: %v", err)
+	}
 }
 
 func main() {
 	log.Printf("Starting %s in %s mode", config.AppSetting.Name, config.ServerSetting.RunMode)
 
+	// Stop on interrupt or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Purge expired records from the trash in the background
+	go services.NewTrashService().RunPurgeJob(ctx)
+
+	// Write buffered lesson progress to the database in the background
+	progressService := services.NewProgressService()
+	go progressService.RunFlushJob(ctx)
+
 	// Create and run server
 	server := api.NewServer()
-	server.Run()
+	server.Run(ctx)
+
+	// Write the progress buffered since the last flush before exiting
+	progressService.Flush()
+	log.Printf("Stopped %s", config.AppSetting.Name)
 }
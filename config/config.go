@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/ini.v1"
@@ -30,10 +31,96 @@ type Database struct {
 }
 
 type App struct {
-	Name                   string
-	JWTSecret              string
-	TokenExpireTime        int
-	RefreshTokenExpireTime int
+	Name                      string
+	JWTSecret                 string
+	TokenExpireTime           int
+	RefreshTokenExpireTime    int
+	ProgressCompleteThreshold int
+	ProgressFlushInterval     int
+	FrontendURL               string
+	RequireEmailVerification  string
+}
+
+type JWT struct {
+	KeysDir     string
+	ActiveKeyID string
+	AcceptHS256 bool
+}
+
+type SIWE struct {
+	Domain          string
+	ChainIDs        []int64
+	NonceExpireTime int
+}
+
+type MFA struct {
+	Issuer              string
+	RequiredForAdmins   bool
+	EncryptionKey       string
+	ChallengeExpireTime int
+}
+
+type Lockout struct {
+	FreeAttempts           int
+	MaxAttempts            int
+	BaseDelay              int
+	MaxDelay               int
+	Duration               int
+	AttemptWindow          int
+	ForgotPasswordPerEmail int
+	ForgotPasswordPerIP    int
+	ForgotPasswordWindow   int
+}
+
+type Password struct {
+	Algorithm           string
+	BcryptCost          int
+	Argon2Memory        int
+	Argon2Iterations    int
+	Argon2Parallelism   int
+	Argon2SaltLength    int
+	Argon2KeyLength     int
+	MinLength           int
+	CommonPasswordsFile string
+}
+
+type OIDC struct {
+	StateExpireTime int
+}
+
+// OIDCProvider is an OpenID Connect identity provider configured in an [oidc.<name>] section
+type OIDCProvider struct {
+	Name         string `ini:"-"`
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type WebAuthn struct {
+	RPID                string
+	RPDisplayName       string
+	RPOrigins           []string
+	ChallengeExpireTime int
+}
+
+type Trash struct {
+	RetentionDays int
+	PurgeInterval int
+}
+
+type Mail struct {
+	Driver    string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	FromName  string
+	OutputDir string
+	Timeout   time.Duration
 }
 
 type Redis struct {
@@ -48,6 +135,16 @@ var (
 	DatabaseSetting = &Database{}
 	AppSetting      = &App{}
 	RedisSetting    = &Redis{}
+	MailSetting     = &Mail{}
+	JWTSetting      = &JWT{}
+	SIWESetting     = &SIWE{}
+	MFASetting      = &MFA{}
+	LockoutSetting  = &Lockout{}
+	OIDCSetting     = &OIDC{}
+	PasswordSetting = &Password{}
+	WebAuthnSetting = &WebAuthn{}
+	TrashSetting    = &Trash{}
+	OIDCProviders   = map[string]*OIDCProvider{}
 )
 
 // Setup initializes the configuration instance
@@ -65,6 +162,23 @@ func Setup() {
 		mapTo(cfg, "database", DatabaseSetting)
 		mapTo(cfg, "app", AppSetting)
 		mapTo(cfg, "redis", RedisSetting)
+		mapTo(cfg, "mail", MailSetting)
+		mapTo(cfg, "jwt", JWTSetting)
+		mapTo(cfg, "siwe", SIWESetting)
+		mapTo(cfg, "mfa", MFASetting)
+		mapTo(cfg, "lockout", LockoutSetting)
+		mapTo(cfg, "password", PasswordSetting)
+		mapTo(cfg, "oidc", OIDCSetting)
+		mapTo(cfg, "webauthn", WebAuthnSetting)
+		mapTo(cfg, "trash", TrashSetting)
+
+		// Every [oidc.<name>] section configures a provider
+		for _, section := range cfg.Section("oidc").ChildSections() {
+			provider := &OIDCProvider{}
+			mapTo(cfg, section.Name(), provider)
+			provider.Name = strings.TrimPrefix(section.Name(), "oidc.")
+			OIDCProviders[provider.Name] = provider
+		}
 	}
 
 	// Override with environment variables if they exist
@@ -73,6 +187,7 @@ func Setup() {
 	// Set timeouts
 	ServerSetting.ReadTimeout = ServerSetting.ReadTimeout * time.Second
 	ServerSetting.WriteTimeout = ServerSetting.WriteTimeout * time.Second
+	MailSetting.Timeout = MailSetting.Timeout * time.Second
 }
 
 // mapTo maps section to struct
@@ -153,6 +268,22 @@ func loadEnvVariables() {
 			AppSetting.RefreshTokenExpireTime = time
 		}
 	}
+	if env := os.Getenv("APP_PROGRESS_COMPLETE_THRESHOLD"); env != "" {
+		if threshold, err := strconv.Atoi(env); err == nil {
+			AppSetting.ProgressCompleteThreshold = threshold
+		}
+	}
+	if env := os.Getenv("APP_PROGRESS_FLUSH_INTERVAL"); env != "" {
+		if interval, err := strconv.Atoi(env); err == nil {
+			AppSetting.ProgressFlushInterval = interval
+		}
+	}
+	if env := os.Getenv("APP_FRONTEND_URL"); env != "" {
+		AppSetting.FrontendURL = env
+	}
+	if env := os.Getenv("APP_REQUIRE_EMAIL_VERIFICATION"); env != "" {
+		AppSetting.RequireEmailVerification = env
+	}
 
 	// Redis settings
 	if env := os.Getenv("REDIS_HOST"); env != "" {
@@ -171,4 +302,106 @@ func loadEnvVariables() {
 			RedisSetting.DB = db
 		}
 	}
+
+	// Mail settings
+	if env := os.Getenv("MAIL_DRIVER"); env != "" {
+		MailSetting.Driver = env
+	}
+	if env := os.Getenv("MAIL_HOST"); env != "" {
+		MailSetting.Host = env
+	}
+	if env := os.Getenv("MAIL_PORT"); env != "" {
+		if port, err := strconv.Atoi(env); err == nil {
+			MailSetting.Port = port
+		}
+	}
+	if env := os.Getenv("MAIL_USERNAME"); env != "" {
+		MailSetting.Username = env
+	}
+	if env := os.Getenv("MAIL_PASSWORD"); env != "" {
+		MailSetting.Password = env
+	}
+	if env := os.Getenv("MAIL_FROM"); env != "" {
+		MailSetting.From = env
+	}
+	if env := os.Getenv("MAIL_FROM_NAME"); env != "" {
+		MailSetting.FromName = env
+	}
+	if env := os.Getenv("MAIL_OUTPUT_DIR"); env != "" {
+		MailSetting.OutputDir = env
+	}
+	if env := os.Getenv("MAIL_TIMEOUT"); env != "" {
+		if timeout, err := strconv.Atoi(env); err == nil {
+			MailSetting.Timeout = time.Duration(timeout)
+		}
+	}
+
+	// JWT settings
+	if env := os.Getenv("JWT_KEYS_DIR"); env != "" {
+		JWTSetting.KeysDir = env
+	}
+	if env := os.Getenv("JWT_ACTIVE_KEY_ID"); env != "" {
+		JWTSetting.ActiveKeyID = env
+	}
+	if env := os.Getenv("JWT_ACCEPT_HS256"); env != "" {
+		if accept, err := strconv.ParseBool(env); err == nil {
+			JWTSetting.AcceptHS256 = accept
+		}
+	}
+
+	// Sign-In with Ethereum settings
+	if env := os.Getenv("SIWE_DOMAIN"); env != "" {
+		SIWESetting.Domain = env
+	}
+	if env := os.Getenv("SIWE_CHAIN_IDS"); env != "" {
+		var chainIDs []int64
+		for _, value := range strings.Split(env, ",") {
+			if chainID, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				chainIDs = append(chainIDs, chainID)
+			}
+		}
+		SIWESetting.ChainIDs = chainIDs
+	}
+
+	// Two-factor authentication settings
+	if env := os.Getenv("MFA_REQUIRED_FOR_ADMINS"); env != "" {
+		if required, err := strconv.ParseBool(env); err == nil {
+			MFASetting.RequiredForAdmins = required
+		}
+	}
+	if env := os.Getenv("MFA_ENCRYPTION_KEY"); env != "" {
+		MFASetting.EncryptionKey = env
+	}
+
+	// Passkey relying party, which has to match the frontend origin
+	if env := os.Getenv("WEBAUTHN_RP_ID"); env != "" {
+		WebAuthnSetting.RPID = env
+	}
+	if env := os.Getenv("WEBAUTHN_RP_ORIGINS"); env != "" {
+		var origins []string
+		for _, value := range strings.Split(env, ",") {
+			if origin := strings.TrimSpace(value); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		WebAuthnSetting.RPOrigins = origins
+	}
+
+	// Days deleted records are kept before they are purged
+	if env := os.Getenv("TRASH_RETENTION_DAYS"); env != "" {
+		if days, err := strconv.Atoi(env); err == nil {
+			TrashSetting.RetentionDays = days
+		}
+	}
+
+	// OpenID Connect provider credentials, e.g. OIDC_GOOGLE_CLIENT_SECRET
+	for name, provider := range OIDCProviders {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		if env := os.Getenv(prefix + "CLIENT_ID"); env != "" {
+			provider.ClientID = env
+		}
+		if env := os.Getenv(prefix + "CLIENT_SECRET"); env != "" {
+			provider.ClientSecret = env
+		}
+	}
 }
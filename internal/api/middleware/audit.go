@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxAuditPathLength is the longest request path stored in an audit event
+const maxAuditPathLength = 255
+
+// maxAuditUserAgentLength is the longest user agent stored in an audit event
+const maxAuditUserAgentLength = 512
+
+// maxDeniedAuditEvents is the number of denied requests recorded per actor and
+// minute. Anyone signed in can send denied requests, so a flood of them is
+// logged once and not recorded further.
+const maxDeniedAuditEvents = 20
+
+// AuditMiddleware records every request that changes data in the audit log,
+// including requests that failed or were denied, up to maxDeniedAuditEvents.
+// Services record the changes they make together with them; other requests are
+// recorded once handled, with targetType, or the :type route parameter when it
+// is empty, and the :id route parameter identifying the record acted on.
+func AuditMiddleware(targetType string) gin.HandlerFunc {
+	auditService := services.NewAuditService()
+	cache := redis.NewCache()
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		if len(path) > maxAuditPathLength {
+			path = path[:maxAuditPathLength]
+		}
+		userAgent := c.Request.UserAgent()
+		if len(userAgent) > maxAuditUserAgentLength {
+			userAgent = userAgent[:maxAuditUserAgentLength]
+		}
+
+		record := &services.AuditRecord{
+			ActorRole:  c.GetString("role"),
+			Action:     c.Request.Method + " " + c.FullPath(),
+			TargetType: targetType,
+			Method:     c.Request.Method,
+			Path:       path,
+			IPAddress:  c.ClientIP(),
+			UserAgent:  userAgent,
+			RequestID:  c.GetString("request_id"),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			actorID := userID.(uuid.UUID)
+			record.ActorID = &actorID
+		}
+		if record.TargetType == "" {
+			record.TargetType = c.Param("type")
+		}
+		if targetID, err := uuid.Parse(c.Param("id")); err == nil {
+			record.TargetID = &targetID
+		}
+
+		c.Set("audit", record)
+		c.Next()
+
+		status := c.Writer.Status()
+		if (status == http.StatusUnauthorized || status == http.StatusForbidden) && !allowDeniedAudit(cache, record) {
+			return
+		}
+
+		// The response has been sent, so a failure can only be reported
+		if err := auditService.Write(record, status); err != nil {
+			log.Printf("SECURITY: failed to write audit event for %s %s (request %s): %v", record.Method, record.Path, record.RequestID, err)
+		}
+	}
+}
+
+// allowDeniedAudit counts a denied request of the record's actor, or of its IP
+// address without an actor, and reports whether it is still within
+// maxDeniedAuditEvents. Denied requests are recorded when they cannot be
+// counted.
+func allowDeniedAudit(cache *redis.Cache, record *services.AuditRecord) bool {
+	actor := record.IPAddress
+	if record.ActorID != nil {
+		actor = record.ActorID.String()
+	}
+
+	count, err := cache.IncrementCounter(context.Background(), "audit:denied:"+actor, time.Minute)
+	if err != nil {
+		return true
+	}
+	if count == maxDeniedAuditEvents+1 {
+		log.Printf("SECURITY: more than %d denied admin requests from %s in a minute, not recording more", maxDeniedAuditEvents, actor)
+	}
+	return count <= maxDeniedAuditEvents
+}
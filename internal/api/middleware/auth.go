@@ -1,14 +1,18 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
 
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
 	"github.com/0xBoji/web3-edu-core/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthMiddleware is a middleware for authentication
 func AuthMiddleware() gin.HandlerFunc {
+	tokenService := services.NewTokenService()
+
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -34,10 +38,52 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Set the user ID and role in the context
+		// Reject tokens revoked by logout, password reset or role changes
+		revoked, err := tokenService.IsRevoked(claims)
+		if err != nil {
+			utils.ServerErrorResponse(c)
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.UnauthorizedResponse(c)
+			c.Abort()
+			return
+		}
+
+		// Set the user ID, role and session in the context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("mfa", claims.MFA)
+
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware sets the user in the context when a valid token is
+// present, but lets anonymous requests through
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	tokenService := services.NewTokenService()
+
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			claims, err := utils.ParseToken(parts[1])
+			if err == nil {
+				if revoked, err := tokenService.IsRevoked(claims); err != nil || revoked {
+					claims = nil
+				}
+			}
+			if claims != nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("email", claims.Email)
+				c.Set("role", claims.Role)
+				c.Set("session_id", claims.SessionID)
+				c.Set("mfa", claims.MFA)
+			}
+		}
 
 		c.Next()
 	}
@@ -67,3 +113,43 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// RequirePermission is a middleware for permission-based authorization. The
+// permissions of the caller's role are looked up in the roles table.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	roleService := services.NewRoleService()
+
+	return func(c *gin.Context) {
+		// Get the role from the context
+		role, exists := c.Get("role")
+		if !exists {
+			utils.UnauthorizedResponse(c)
+			c.Abort()
+			return
+		}
+
+		// Privileged roles may have to sign in with a second factor
+		if services.MFARequiredForRole(role.(string)) && !c.GetBool("mfa") {
+			utils.ErrorResponse(c, http.StatusForbidden, "two-factor authentication is required")
+			c.Abort()
+			return
+		}
+
+		// Every permission must be granted by the role
+		for _, permission := range permissions {
+			allowed, err := roleService.HasPermission(role.(string), permission)
+			if err != nil {
+				utils.ServerErrorResponse(c)
+				c.Abort()
+				return
+			}
+			if !allowed {
+				utils.ForbiddenResponse(c)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/0xBoji/web3-edu-core/config"
 	"github.com/0xBoji/web3-edu-core/internal/api/middleware"
@@ -31,6 +33,7 @@ func NewServer() *Server {
 
 	// Apply global middleware
 	router.Use(middleware.CorsMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -43,8 +46,11 @@ func NewServer() *Server {
 	}
 }
 
-// Run starts the server
-func (s *Server) Run() {
+// shutdownTimeout is how long requests in flight may take to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// Run starts the server and shuts it down gracefully once the context is cancelled
+func (s *Server) Run(ctx context.Context) {
 	addr := fmt.Sprintf("%s:%d", config.ServerSetting.Host, config.ServerSetting.HttpPort)
 	server := &http.Server{
 		Addr:         addr,
@@ -53,6 +59,17 @@ func (s *Server) Run() {
 		WriteTimeout: config.ServerSetting.WriteTimeout,
 	}
 
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down the server: %v", err)
+		}
+	}()
+
 	log.Printf("Server is running on %s", addr)
-	server.ListenAndServe()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Server stopped: %v", err)
+	}
 }
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditHandler handles the audit log of privileged actions
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// auditRecord returns the audit record started by the audit middleware, or
+// nil on routes that are not audited
+func auditRecord(c *gin.Context) *services.AuditRecord {
+	if record, ok := c.Get("audit"); ok {
+		return record.(*services.AuditRecord)
+	}
+	return nil
+}
+
+// auditFilter reads the audit log filter from the query string
+func auditFilter(c *gin.Context) (services.AuditFilter, error) {
+	filter := services.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	if value := c.Query("actor_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid actor ID")
+		}
+		filter.ActorID = &id
+	}
+	if value := c.Query("target_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid target ID")
+		}
+		filter.TargetID = &id
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid from time, use RFC 3339")
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid to time, use RFC 3339")
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// @Summary List audit events
+// @Description Search the audit log of privileged actions, newest first (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param actor_id query string false "Filter by the user who acted"
+// @Param action query string false "Filter by action, e.g. course.delete or user.role_grant"
+// @Param target_type query string false "Filter by target type (course, lesson, user, category, review)"
+// @Param target_id query string false "Filter by target ID"
+// @Param request_id query string false "Filter by request ID"
+// @Param from query string false "Only events at or after this time (RFC 3339)"
+// @Param to query string false "Only events before this time (RFC 3339)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20)"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.AuditEventResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/audit [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	events, total, err := h.auditService.List(filter, page, pageSize)
+	if err != nil {
+		handleAuditError(c, err)
+		return
+	}
+
+	// Set pagination headers
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(page))
+	c.Header("X-Page-Size", strconv.Itoa(pageSize))
+
+	utils.SuccessResponse(c, events)
+}
+
+// @Summary Export audit events
+// @Description Export the audit events matching the filters as newline-delimited JSON, oldest first (admin only)
+// @Tags admin
+// @Produce application/x-ndjson
+// @Param actor_id query string false "Filter by the user who acted"
+// @Param action query string false "Filter by action"
+// @Param target_type query string false "Filter by target type"
+// @Param target_id query string false "Filter by target ID"
+// @Param request_id query string false "Filter by request ID"
+// @Param from query string false "Only events at or after this time (RFC 3339)"
+// @Param to query string false "Only events before this time (RFC 3339)"
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/audit/export [get]
+func (h *AuditHandler) Export(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		handleAuditError(c, err)
+		return
+	}
+
+	// The log can be large, so it is streamed. An error after the first line
+	// can only cut the export short.
+	filename := "audit-" + time.Now().UTC().Format("20060102T150405Z") + ".ndjson"
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+
+	if err := h.auditService.ExportNDJSON(filter, c.Writer); err != nil {
+		log.Printf("Failed to export audit events: %v", err)
+	}
+}
+
+// handleAuditError maps audit service errors to HTTP responses
+func handleAuditError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "from must be before to":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
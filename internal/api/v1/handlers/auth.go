@@ -1,19 +1,31 @@
 package handlers
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/0xBoji/web3-edu-core/internal/domain/services"
 	"github.com/0xBoji/web3-edu-core/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService    *services.AuthService
+	siweService    *services.SIWEService
+	mfaService     *services.MFAService
+	oidcService    *services.OIDCService
+	passkeyService *services.PasskeyService
 }
 
 // NewAuthHandler creates a new auth handler
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
-		authService: services.NewAuthService(),
+		authService:    services.NewAuthService(),
+		siweService:    services.NewSIWEService(),
+		mfaService:     services.NewMFAService(),
+		oidcService:    services.NewOIDCService(),
+		passkeyService: services.NewPasskeyService(),
 	}
 }
 
@@ -34,7 +46,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Register(req)
+	resp, err := h.authService.Register(req, clientInfo(c, req.DeviceName))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -45,13 +57,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login handles the login request
 // @Summary Login a user
-// @Description Login a user with email and password
+// @Description Login a user with email and password. Users with two-factor authentication get an mfa_token to complete the login with /auth/2fa/verify.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body services.LoginRequest true "Login Request"
 // @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
 // @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req services.LoginRequest
@@ -60,7 +73,51 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Login(req)
+	resp, err := h.authService.Login(req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// SIWENonce handles the Sign-In with Ethereum nonce request
+// @Summary Get a Sign-In with Ethereum nonce
+// @Description Issue a single-use nonce to embed in an EIP-4361 message
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.Response{data=services.NonceResponse} "Success"
+// @Failure 500 {object} utils.Response "Internal Server Error"
+// @Router /auth/siwe/nonce [get]
+func (h *AuthHandler) SIWENonce(c *gin.Context) {
+	resp, err := h.siweService.Nonce()
+	if err != nil {
+		utils.ServerErrorResponse(c)
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// SIWEVerify handles the Sign-In with Ethereum verify request
+// @Summary Sign in with Ethereum
+// @Description Verify a signed EIP-4361 message and log the wallet in. A wallet-only account is created on first sign in.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.SIWEVerifyRequest true "SIWE Verify Request"
+// @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Router /auth/siwe/verify [post]
+func (h *AuthHandler) SIWEVerify(c *gin.Context) {
+	var req services.SIWEVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.siweService.Verify(req, clientInfo(c, req.DeviceName))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -69,6 +126,136 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	utils.SuccessResponse(c, resp)
 }
 
+// PasskeyChallenge handles the passkey login challenge request
+// @Summary Get a passkey login challenge
+// @Description Get the options to pass to navigator.credentials.get() and the challenge ID to send back with the assertion
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.Response{data=services.PasskeyLoginResponse} "Success"
+// @Failure 500 {object} utils.Response "Internal Server Error"
+// @Router /auth/passkeys/challenge [post]
+func (h *AuthHandler) PasskeyChallenge(c *gin.Context) {
+	resp, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		utils.ServerErrorResponse(c)
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// PasskeyVerify handles the passkey login request
+// @Summary Sign in with a passkey
+// @Description Verify the assertion of a passkey for a login challenge and log its owner in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.PasskeyLoginRequest true "Passkey Login Request"
+// @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Router /auth/passkeys/verify [post]
+func (h *AuthHandler) PasskeyVerify(c *gin.Context) {
+	var req services.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.passkeyService.FinishLogin(req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// OIDCProviders handles the list identity providers request
+// @Summary List identity providers
+// @Description List the OpenID Connect providers users can sign in with
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]services.OIDCProviderResponse} "Success"
+// @Router /auth/oidc/providers [get]
+func (h *AuthHandler) OIDCProviders(c *gin.Context) {
+	utils.SuccessResponse(c, h.oidcService.Providers())
+}
+
+// OIDCAuthorize handles the start of an identity provider login
+// @Summary Start an identity provider login
+// @Description Get the authorization URL of an OpenID Connect provider. The provider redirects back to the configured redirect URL with a code and state.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} utils.Response{data=services.OIDCAuthorizeResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 404 {object} utils.Response "Not Found"
+// @Router /auth/oidc/{provider}/authorize [get]
+func (h *AuthHandler) OIDCAuthorize(c *gin.Context) {
+	resp, err := h.oidcService.Authorize(c.Param("provider"), uuid.Nil)
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// OIDCCallback handles the end of an identity provider login
+// @Summary Complete an identity provider login
+// @Description Exchange the code and state from the provider redirect for tokens. A learner account is created on first sign in.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body services.OIDCCallbackRequest true "OIDC Callback Request"
+// @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 404 {object} utils.Response "Not Found"
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var req services.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.oidcService.Callback(c.Param("provider"), req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// VerifyMFA handles the second step of a two-factor login
+// @Summary Complete a two-factor login
+// @Description Exchange the mfa_token returned by login and a TOTP or recovery code for tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFAVerifyRequest true "MFA Verify Request"
+// @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req services.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.mfaService.Verify(req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
 // RefreshToken handles the refresh token request
 // @Summary Refresh access token
 // @Description Refresh access token using refresh token
@@ -88,7 +275,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.RefreshToken(req.RefreshToken)
+	resp, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(c, ""))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -99,7 +286,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 // Logout handles the logout request
 // @Summary Logout a user
-// @Description Logout a user by invalidating refresh token
+// @Description Logout a user by invalidating the refresh token, and the access token when it is sent in the Authorization header
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -116,7 +303,16 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.Logout(req.RefreshToken)
+	// The access token is optional; when it is sent it is revoked as well
+	var accessClaims *utils.Claims
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		if claims, err := utils.ParseToken(parts[1]); err == nil {
+			accessClaims = claims
+		}
+	}
+
+	err := h.authService.Logout(req.RefreshToken, accessClaims)
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -134,6 +330,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // @Param request body services.ForgotPasswordRequest true "Forgot Password Request"
 // @Success 200 {object} utils.Response{data=object{message=string}} "Success"
 // @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
 // @Router /auth/forgot-password [post]
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req services.ForgotPasswordRequest
@@ -142,9 +339,9 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.ForgotPassword(req)
+	err := h.authService.ForgotPassword(req, clientInfo(c, ""))
 	if err != nil {
-		utils.ErrorResponse(c, 400, err.Error())
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
 		return
 	}
 
@@ -152,6 +349,60 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	utils.SuccessResponse(c, gin.H{"message": "if your email is registered, you will receive a password reset link"})
 }
 
+// RequestMagicLink handles the magic link request
+// @Summary Request a magic login link
+// @Description Email a single-use login link. Keep the returned nonce in the browser and send it back with the token from the link.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MagicLinkRequest true "Magic Link Request"
+// @Success 200 {object} utils.Response{data=services.MagicLinkResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
+// @Router /auth/magic-link [post]
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var req services.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.authService.RequestMagicLink(req, clientInfo(c, ""))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	// Always return success to prevent email enumeration
+	utils.SuccessResponse(c, resp)
+}
+
+// VerifyMagicLink handles the magic link verify request
+// @Summary Sign in with a magic link
+// @Description Exchange the token from a magic link and the nonce returned when it was requested for tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.MagicLinkVerifyRequest true "Magic Link Verify Request"
+// @Success 200 {object} utils.Response{data=services.TokenResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Router /auth/magic-link/verify [post]
+func (h *AuthHandler) VerifyMagicLink(c *gin.Context) {
+	var req services.MagicLinkVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	resp, err := h.authService.VerifyMagicLink(req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
 // ResetPassword handles the reset password request
 // @Summary Reset password
 // @Description Reset password using token
@@ -177,3 +428,91 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 
 	utils.SuccessResponse(c, gin.H{"message": "password reset successfully"})
 }
+
+// VerifyEmail handles the verify email request
+// @Summary Verify email address
+// @Description Verify the email address of a user using the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.VerifyEmailRequest true "Verify Email Request"
+// @Success 200 {object} utils.Response{data=services.UserResponse} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req services.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(req)
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, user)
+}
+
+// ResendVerification handles the resend verification email request
+// @Summary Resend verification email
+// @Description Send a new verification email to an unverified address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.ResendVerificationRequest true "Resend Verification Request"
+// @Success 200 {object} utils.Response{data=object{message=string}} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req services.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	err := h.authService.ResendVerification(req, clientInfo(c, ""))
+	if err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	// Always return success to prevent email enumeration
+	utils.SuccessResponse(c, gin.H{"message": "if your email is registered and not yet verified, you will receive a verification link"})
+}
+
+// authErrorStatus maps auth service errors to HTTP status codes
+func authErrorStatus(err error) int {
+	switch err.Error() {
+	case "too many failed attempts, try again later", "too many requests, try again later":
+		return http.StatusTooManyRequests
+	case "unknown identity provider":
+		return http.StatusNotFound
+	case "identity provider is unavailable":
+		return http.StatusBadGateway
+	}
+	return http.StatusBadRequest
+}
+
+// clientInfo describes the client making the request for session tracking
+func clientInfo(c *gin.Context, deviceName string) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		DeviceName: deviceName,
+	}
+}
+
+// JWKS handles the JSON Web Key Set request
+// @Summary JSON Web Key Set
+// @Description Public keys used to verify access tokens, identified by kid
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.JWKSet "Success"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.PublicJWKS())
+}
@@ -82,7 +82,7 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryService.Create(req)
+	category, err := h.categoryService.Create(req, auditRecord(c))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -117,7 +117,7 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryService.Update(id, req)
+	category, err := h.categoryService.Update(id, req, auditRecord(c))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -128,7 +128,7 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 
 // Delete handles the delete category request
 // @Summary Delete a category
-// @Description Delete a category (admin only)
+// @Description Move a category to the trash (admin only)
 // @Tags admin,categories
 // @Accept json
 // @Produce json
@@ -145,7 +145,7 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryService.Delete(id); err != nil {
+	if err := h.categoryService.Delete(id, auditRecord(c)); err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
 	}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/0xBoji/web3-edu-core/internal/domain/services"
 	"github.com/0xBoji/web3-edu-core/internal/utils"
@@ -12,13 +13,17 @@ import (
 
 // CourseHandler handles course-related requests
 type CourseHandler struct {
-	courseService *services.CourseService
+	courseService   *services.CourseService
+	progressService *services.ProgressService
+	reviewService   *services.ReviewService
 }
 
 // NewCourseHandler creates a new course handler
 func NewCourseHandler() *CourseHandler {
 	return &CourseHandler{
-		courseService: services.NewCourseService(),
+		courseService:   services.NewCourseService(),
+		progressService: services.NewProgressService(),
+		reviewService:   services.NewReviewService(),
 	}
 }
 
@@ -74,7 +79,7 @@ func (h *CourseHandler) GetFeatured(c *gin.Context) {
 }
 
 // @Summary Get course by ID
-// @Description Get a course by its ID
+// @Description Get a published or archived course by its ID. Authenticated, enrolled callers also receive their progress. The instructor and admins can preview drafts.
 // @Tags courses
 // @Accept json
 // @Produce json
@@ -91,7 +96,8 @@ func (h *CourseHandler) Get(c *gin.Context) {
 		return
 	}
 
-	course, err := h.courseService.GetByID(id)
+	viewerID, role := courseViewer(c)
+	course, err := h.courseService.GetByID(id, viewerID, role)
 	if err != nil {
 		if err.Error() == "course not found" {
 			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
@@ -101,9 +107,57 @@ func (h *CourseHandler) Get(c *gin.Context) {
 		return
 	}
 
+	// Include the learner's progress when the caller is enrolled
+	if userID, exists := c.Get("user_id"); exists {
+		if progress, err := h.progressService.GetCourseProgress(userID.(uuid.UUID), id); err == nil {
+			course.Progress = progress
+		}
+	}
+
 	utils.SuccessResponse(c, course)
 }
 
+// @Summary Get course progress
+// @Description Get the authenticated user's progress summary for a course, including the lesson to resume
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.CourseProgressResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /courses/{id}/progress [get]
+func (h *CourseHandler) GetProgress(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Parse course ID
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	progress, err := h.progressService.GetCourseProgress(userID.(uuid.UUID), courseID)
+	if err != nil {
+		if err.Error() == "not enrolled in this course" {
+			utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, progress)
+}
+
 // @Summary Get course lessons
 // @Description Get lessons for a course
 // @Tags courses
@@ -122,15 +176,93 @@ func (h *CourseHandler) GetLessons(c *gin.Context) {
 		return
 	}
 
-	lessons, err := h.courseService.GetLessons(id)
+	viewerID, role := courseViewer(c)
+	lessons, err := h.courseService.GetLessons(id, viewerID, role)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		handleCourseError(c, err)
 		return
 	}
 
 	utils.SuccessResponse(c, lessons)
 }
 
+// @Summary Get course reviews
+// @Description Page through the reviews of a course, newest first. Admins also see hidden reviews.
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} utils.Response{data=object{reviews=[]services.ReviewResponse,total=int,page=int,size=int}}
+// @Failure 400 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /courses/{id}/reviews [get]
+func (h *CourseHandler) GetReviews(c *gin.Context) {
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	reviews, total, err := h.reviewService.List(courseID, c.GetString("role"), page, pageSize)
+	if err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"reviews": reviews,
+		"total":   total,
+		"page":    page,
+		"size":    pageSize,
+	})
+}
+
+// @Summary Add a review to a course
+// @Description Rate an enrolled course from 1 to 5 stars with an optional comment. Each learner reviews a course once.
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param request body services.CreateReviewRequest true "Create Review Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.ReviewResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /courses/{id}/reviews [post]
+func (h *CourseHandler) AddReview(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	var req services.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	review, err := h.reviewService.Create(courseID, userID.(uuid.UUID), req)
+	if err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, review)
+}
+
 // @Summary Enroll in a course
 // @Description Enroll the authenticated user in a course
 // @Tags courses
@@ -166,7 +298,8 @@ func (h *CourseHandler) Enroll(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
 			return
 		}
-		if err.Error() == "already enrolled in this course" {
+		if err.Error() == "already enrolled in this course" || err.Error() == "email address is not verified" ||
+			err.Error() == "course is not open for enrollment" {
 			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -178,7 +311,7 @@ func (h *CourseHandler) Enroll(c *gin.Context) {
 }
 
 // @Summary Create a course
-// @Description Create a new course (admin only)
+// @Description Create a new draft course. Instructors create their own courses; admins may set instructor_id.
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -191,13 +324,16 @@ func (h *CourseHandler) Enroll(c *gin.Context) {
 // @Failure 500 {object} utils.Response
 // @Router /admin/courses [post]
 func (h *CourseHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
 	var req services.CreateCourseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	course, err := h.courseService.Create(req)
+	course, err := h.courseService.Create(userID.(uuid.UUID), role.(string), req, auditRecord(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -207,7 +343,7 @@ func (h *CourseHandler) Create(c *gin.Context) {
 }
 
 // @Summary Update a course
-// @Description Update an existing course (admin only)
+// @Description Update an existing course (admin, or the course instructor)
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -222,6 +358,9 @@ func (h *CourseHandler) Create(c *gin.Context) {
 // @Failure 500 {object} utils.Response
 // @Router /admin/courses/{id} [put]
 func (h *CourseHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
@@ -234,13 +373,9 @@ func (h *CourseHandler) Update(c *gin.Context) {
 		return
 	}
 
-	course, err := h.courseService.Update(id, req)
+	course, err := h.courseService.Update(id, userID.(uuid.UUID), role.(string), req, auditRecord(c))
 	if err != nil {
-		if err.Error() == "course not found" {
-			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		handleCourseError(c, err)
 		return
 	}
 
@@ -248,7 +383,7 @@ func (h *CourseHandler) Update(c *gin.Context) {
 }
 
 // @Summary Delete a course
-// @Description Delete a course (admin only)
+// @Description Move a course and its lessons to the trash (admin, or the instructor of a draft course)
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -262,21 +397,157 @@ func (h *CourseHandler) Update(c *gin.Context) {
 // @Failure 500 {object} utils.Response
 // @Router /admin/courses/{id} [delete]
 func (h *CourseHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
 		return
 	}
 
-	err = h.courseService.Delete(id)
+	err = h.courseService.Delete(id, userID.(uuid.UUID), role.(string), auditRecord(c))
 	if err != nil {
-		if err.Error() == "course not found" {
-			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		handleCourseError(c, err)
 		return
 	}
 
 	utils.SuccessResponse(c, nil)
 }
+
+// @Summary List managed courses
+// @Description List courses in any status, newest change first. Admins see all courses, instructors their own.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param status query string false "Filter by status (draft, review, published, archived)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10)"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.CourseResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses [get]
+func (h *CourseHandler) ListManaged(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	status := c.Query("status")
+
+	courses, total, err := h.courseService.ListManaged(userID.(uuid.UUID), role.(string), status, page, pageSize)
+	if err != nil {
+		handleCourseError(c, err)
+		return
+	}
+
+	// Set pagination headers
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(page))
+	c.Header("X-Page-Size", strconv.Itoa(pageSize))
+
+	utils.SuccessResponse(c, courses)
+}
+
+// @Summary Change course status
+// @Description Move a course through the publishing workflow: draft → review → published → archived. Instructors submit drafts, withdraw them from review and archive their courses. Approving (review → published), rejecting (review → draft, with a comment) and restoring (archived → published) need the course:publish permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param request body services.CourseStatusRequest true "Course Status Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.CourseResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses/{id}/status [put]
+func (h *CourseHandler) ChangeStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	var req services.CourseStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	course, err := h.courseService.ChangeStatus(id, userID.(uuid.UUID), role.(string), req, auditRecord(c))
+	if err != nil {
+		handleCourseError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, course)
+}
+
+// @Summary Get course status history
+// @Description List the status changes of a course with their comments, newest first (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.CourseStatusChangeResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses/{id}/status/history [get]
+func (h *CourseHandler) GetStatusHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	changes, err := h.courseService.GetStatusHistory(id, userID.(uuid.UUID), role.(string))
+	if err != nil {
+		handleCourseError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, changes)
+}
+
+// courseViewer returns the caller of a public course route, or uuid.Nil when
+// the request is anonymous
+func courseViewer(c *gin.Context) (uuid.UUID, string) {
+	userID, _ := c.Get("user_id")
+	viewerID, _ := userID.(uuid.UUID)
+	return viewerID, c.GetString("role")
+}
+
+// handleCourseError maps course service errors to HTTP responses
+func handleCourseError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "course not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case err.Error() == "you are not the instructor of this course", err.Error() == "you are not allowed to publish courses":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case err.Error() == "course status was changed by another request":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case err.Error() == "invalid course status", err.Error() == "only draft courses can be deleted",
+		err.Error() == "a comment is required to reject a course",
+		strings.HasPrefix(err.Error(), "cannot change course status"):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
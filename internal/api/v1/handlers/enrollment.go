@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EnrollmentHandler handles enrollment-related requests
+type EnrollmentHandler struct {
+	enrollmentService *services.EnrollmentService
+}
+
+// NewEnrollmentHandler creates a new enrollment handler
+func NewEnrollmentHandler() *EnrollmentHandler {
+	return &EnrollmentHandler{
+		enrollmentService: services.NewEnrollmentService(),
+	}
+}
+
+// @Summary List my enrollments
+// @Description List the courses the authenticated user is enrolled in, with progress
+// @Tags enrollments
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=object{enrollments=[]services.EnrollmentResponse,total=int,page=int,size=int}}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /enrollments [get]
+// @Router /users/me/enrollments [get]
+func (h *EnrollmentHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	enrollments, total, err := h.enrollmentService.ListByUser(userID.(uuid.UUID), page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"enrollments": enrollments,
+		"total":       total,
+		"page":        page,
+		"size":        pageSize,
+	})
+}
+
+// @Summary Enroll in a course
+// @Description Enroll the authenticated user in a course
+// @Tags enrollments
+// @Accept json
+// @Produce json
+// @Param request body services.CreateEnrollmentRequest true "Enrollment data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.EnrollmentResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /enrollments [post]
+func (h *EnrollmentHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.CreateEnrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	enrollment, err := h.enrollmentService.Create(userID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, enrollment)
+}
+
+// @Summary Unenroll from a course
+// @Description Remove an enrollment of the authenticated user (admins may remove any enrollment)
+// @Tags enrollments
+// @Accept json
+// @Produce json
+// @Param id path string true "Enrollment ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /enrollments/{id} [delete]
+func (h *EnrollmentHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid enrollment ID")
+		return
+	}
+
+	if err := h.enrollmentService.Delete(id, userID.(uuid.UUID), role.(string)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// @Summary List course roster
+// @Description Page through the learners enrolled in a course (admin, or the course instructor)
+// @Tags courses
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=object{enrollments=[]services.RosterEntryResponse,total=int,page=int,size=int}}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /courses/{id}/enrollments [get]
+func (h *EnrollmentHandler) ListByCourse(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	roster, total, err := h.enrollmentService.ListByCourse(courseID, userID.(uuid.UUID), role.(string), page, pageSize)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"enrollments": roster,
+		"total":       total,
+		"page":        page,
+		"size":        pageSize,
+	})
+}
+
+// @Summary Export course roster
+// @Description Export the learners enrolled in a course as CSV (admin, or the course instructor)
+// @Tags courses
+// @Produce text/csv
+// @Param id path string true "Course ID"
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /courses/{id}/enrollments/export [get]
+func (h *EnrollmentHandler) ExportRoster(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	courseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	// Build the CSV first so errors can still be reported as JSON
+	var buf bytes.Buffer
+	if err := h.enrollmentService.ExportRosterCSV(courseID, userID.(uuid.UUID), role.(string), &buf); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"roster-"+courseID.String()+".csv\"")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// handleError maps enrollment service errors to HTTP responses
+func (h *EnrollmentHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "enrollment not found", "course not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "you are not the instructor of this course":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "already enrolled in this course", "email address is not verified":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
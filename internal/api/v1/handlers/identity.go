@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IdentityHandler handles the identity provider accounts linked to the current user
+type IdentityHandler struct {
+	oidcService *services.OIDCService
+}
+
+// NewIdentityHandler creates a new identity handler
+func NewIdentityHandler() *IdentityHandler {
+	return &IdentityHandler{
+		oidcService: services.NewOIDCService(),
+	}
+}
+
+// @Summary List my linked identities
+// @Description List the identity provider accounts linked to the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]services.IdentityResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/identities [get]
+func (h *IdentityHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	identities, err := h.oidcService.ListIdentities(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, identities)
+}
+
+// @Summary Start linking an identity
+// @Description Get the authorization URL of an OpenID Connect provider to link an account at it
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.OIDCAuthorizeResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/identities/{provider}/authorize [post]
+func (h *IdentityHandler) Authorize(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	resp, err := h.oidcService.Authorize(c.Param("provider"), userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, resp)
+}
+
+// @Summary Link an identity
+// @Description Link the provider account from the code and state of the provider redirect to the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body services.OIDCCallbackRequest true "OIDC Callback Request"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.IdentityResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/identities/{provider} [post]
+func (h *IdentityHandler) Link(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	identity, err := h.oidcService.Link(userID.(uuid.UUID), c.Param("provider"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, identity)
+}
+
+// @Summary Unlink an identity
+// @Description Remove a linked identity provider account from the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param id path string true "Identity ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/identities/{id} [delete]
+func (h *IdentityHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid identity ID")
+		return
+	}
+
+	if err := h.oidcService.DeleteIdentity(userID.(uuid.UUID), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// handleError maps identity service errors to HTTP responses
+func (h *IdentityHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "identity not found", "unknown identity provider":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "identity is already linked to your account", "identity is already linked to another account",
+		"an identity of this provider is already linked to your account":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case "invalid or expired state", "failed to sign in with the identity provider",
+		"identity provider did not return an ID token", "invalid ID token",
+		"cannot remove the only sign-in method of this account":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	case "identity provider is unavailable":
+		utils.ErrorResponse(c, http.StatusBadGateway, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LessonHandler handles lesson-related requests
+type LessonHandler struct {
+	lessonService   *services.LessonService
+	progressService *services.ProgressService
+}
+
+// NewLessonHandler creates a new lesson handler
+func NewLessonHandler() *LessonHandler {
+	return &LessonHandler{
+		lessonService:   services.NewLessonService(),
+		progressService: services.NewProgressService(),
+	}
+}
+
+// @Summary Get lesson by ID
+// @Description Get a lesson by its ID. Lessons of unpublished courses are only shown to the course instructor and admins.
+// @Tags lessons
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.LessonResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /lessons/{id} [get]
+func (h *LessonHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	viewerID, role := courseViewer(c)
+	lesson, err := h.lessonService.GetByID(id, viewerID, role)
+	if err != nil {
+		if err.Error() == "lesson not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, lesson)
+}
+
+// @Summary Get lesson progress
+// @Description Get the authenticated user's progress for a lesson
+// @Tags lessons
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.ProgressResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /lessons/{id}/progress [get]
+func (h *LessonHandler) GetProgress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	progress, err := h.progressService.GetProgress(userID.(uuid.UUID), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, progress)
+}
+
+// @Summary Update lesson progress
+// @Description Record the playback position of the authenticated user (heartbeat)
+// @Tags lessons
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Param progress body services.UpdateProgressRequest true "Progress data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.ProgressResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /lessons/{id}/progress [post]
+func (h *LessonHandler) UpdateProgress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	var req services.UpdateProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	progress, err := h.progressService.UpdateProgress(userID.(uuid.UUID), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, progress)
+}
+
+// @Summary Complete a lesson
+// @Description Mark a lesson as completed for the authenticated user
+// @Tags lessons
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.ProgressResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /lessons/{id}/complete [post]
+func (h *LessonHandler) Complete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	progress, err := h.progressService.Complete(userID.(uuid.UUID), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, progress)
+}
+
+// @Summary Create a lesson
+// @Description Create a new lesson (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param lesson body services.CreateLessonRequest true "Lesson data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.LessonResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons [post]
+func (h *LessonHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	var req services.CreateLessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lesson, err := h.lessonService.Create(userID.(uuid.UUID), role.(string), req, auditRecord(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, lesson)
+}
+
+// @Summary Update a lesson
+// @Description Update an existing lesson (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Param lesson body services.UpdateLessonRequest true "Lesson data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.LessonResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons/{id} [put]
+func (h *LessonHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	var req services.UpdateLessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lesson, err := h.lessonService.Update(id, userID.(uuid.UUID), role.(string), req, auditRecord(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, lesson)
+}
+
+// @Summary Delete a lesson
+// @Description Move a lesson to the trash (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons/{id} [delete]
+func (h *LessonHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	if err := h.lessonService.Delete(id, userID.(uuid.UUID), role.(string), auditRecord(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// handleError maps lesson and progress service errors to HTTP responses
+func (h *LessonHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "lesson not found", "course not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "you are not the instructor of this course", "not enrolled in this course":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "order number already exists in this course", "invalid video URL", "invalid YouTube video URL":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MFAHandler handles two-factor authentication settings of the current user
+type MFAHandler struct {
+	mfaService *services.MFAService
+}
+
+// NewMFAHandler creates a new MFA handler
+func NewMFAHandler() *MFAHandler {
+	return &MFAHandler{
+		mfaService: services.NewMFAService(),
+	}
+}
+
+// @Summary Start two-factor setup
+// @Description Generate a TOTP secret with a provisioning URI and QR code. It has to be confirmed with a code within 10 minutes.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.TOTPSetupResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/2fa/setup [post]
+func (h *MFAHandler) Setup(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	setup, err := h.mfaService.Setup(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, setup)
+}
+
+// @Summary Enable two-factor authentication
+// @Description Confirm the pending TOTP secret with a code. The recovery codes are only returned once.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.MFACodeRequest true "Code"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.RecoveryCodesResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/2fa/enable [post]
+func (h *MFAHandler) Enable(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := h.mfaService.Enable(userID.(uuid.UUID), sessionID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, codes)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication with a TOTP or recovery code and the current password
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.DisableMFARequest true "Disable Request"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/2fa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.mfaService.Disable(userID.(uuid.UUID), req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace every recovery code after checking a TOTP or recovery code
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.MFACodeRequest true "Code"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.RecoveryCodesResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/2fa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(userID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, codes)
+}
+
+// handleError maps MFA service errors to HTTP responses
+func (h *MFAHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "two-factor authentication is required for your role":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "two-factor authentication is already enabled", "two-factor authentication is not enabled",
+		"two-factor setup has expired", "invalid two-factor code", "password is incorrect":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
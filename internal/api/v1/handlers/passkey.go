@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PasskeyHandler handles the passkeys of the current user
+type PasskeyHandler struct {
+	passkeyService *services.PasskeyService
+}
+
+// NewPasskeyHandler creates a new passkey handler
+func NewPasskeyHandler() *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: services.NewPasskeyService(),
+	}
+}
+
+// @Summary List my passkeys
+// @Description List the passkeys registered by the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]services.PasskeyResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/passkeys [get]
+func (h *PasskeyHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	passkeys, err := h.passkeyService.List(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, passkeys)
+}
+
+// @Summary Get a passkey registration challenge
+// @Description Get the options to pass to navigator.credentials.create() to register a passkey
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.PasskeyRegistrationResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/passkeys/challenge [post]
+func (h *PasskeyHandler) Challenge(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	challenge, err := h.passkeyService.BeginRegistration(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, challenge)
+}
+
+// @Summary Register a passkey
+// @Description Verify the credential created for the registration challenge and store it as a passkey
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.RegisterPasskeyRequest true "Register Passkey Request"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.PasskeyResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/passkeys [post]
+func (h *PasskeyHandler) Register(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.RegisterPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passkey, err := h.passkeyService.FinishRegistration(userID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, passkey)
+}
+
+// @Summary Rename a passkey
+// @Description Change the name of a passkey of the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param id path string true "Passkey ID"
+// @Param request body services.RenamePasskeyRequest true "Rename Passkey Request"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.PasskeyResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/passkeys/{id} [patch]
+func (h *PasskeyHandler) Rename(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid passkey ID")
+		return
+	}
+
+	var req services.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passkey, err := h.passkeyService.Rename(userID.(uuid.UUID), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, passkey)
+}
+
+// @Summary Remove a passkey
+// @Description Remove a passkey from the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param id path string true "Passkey ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/passkeys/{id} [delete]
+func (h *PasskeyHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid passkey ID")
+		return
+	}
+
+	if err := h.passkeyService.Delete(userID.(uuid.UUID), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// handleError maps passkey service errors to HTTP responses
+func (h *PasskeyHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "passkey not found", "user not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "passkey is already registered":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case "invalid or expired passkey challenge", "invalid passkey response", "passkey verification failed",
+		"name is required", "cannot remove the only sign-in method of this account":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
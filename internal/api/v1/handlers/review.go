@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReviewHandler handles changes to existing course reviews
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler() *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: services.NewReviewService(),
+	}
+}
+
+// @Summary Update my review
+// @Description Change the rating or comment of a review written by the authenticated user
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param request body services.UpdateReviewRequest true "Update Review Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.ReviewResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /reviews/{id} [put]
+func (h *ReviewHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req services.UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	review, err := h.reviewService.Update(id, userID.(uuid.UUID), req)
+	if err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, review)
+}
+
+// @Summary Delete my review
+// @Description Delete a review written by the authenticated user
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /reviews/{id} [delete]
+func (h *ReviewHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	if err := h.reviewService.Delete(id, userID.(uuid.UUID)); err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// @Summary Reply to a review
+// @Description Publish the instructor's reply to a review of their course, replacing any previous reply (admin, or the course instructor)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param request body services.ReplyReviewRequest true "Reply Review Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.ReviewResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /reviews/{id}/reply [post]
+func (h *ReviewHandler) Reply(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req services.ReplyReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	review, err := h.reviewService.Reply(id, userID.(uuid.UUID), role.(string), req)
+	if err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, review)
+}
+
+// @Summary Moderate a review
+// @Description Hide an abusive review or show it again. Hidden reviews are not listed publicly and do not count towards the course rating.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param request body services.ModerateReviewRequest true "Moderate Review Request"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.ReviewResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/reviews/{id}/moderation [put]
+func (h *ReviewHandler) Moderate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req services.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	review, err := h.reviewService.Moderate(id, userID.(uuid.UUID), req, auditRecord(c))
+	if err != nil {
+		handleReviewError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, review)
+}
+
+// handleReviewError maps review service errors to HTTP responses
+func handleReviewError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "course not found", "review not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "you have already reviewed this course":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case "you must be enrolled in this course to review it", "you can only change your own review",
+		"you are not the instructor of this course":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "reply is required":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RevisionHandler handles the revision history of courses and lessons
+type RevisionHandler struct {
+	revisionService *services.RevisionService
+	courseService   *services.CourseService
+	lessonService   *services.LessonService
+}
+
+// NewRevisionHandler creates a new revision handler
+func NewRevisionHandler() *RevisionHandler {
+	return &RevisionHandler{
+		revisionService: services.NewRevisionService(),
+		courseService:   services.NewCourseService(),
+		lessonService:   services.NewLessonService(),
+	}
+}
+
+// @Summary List course revisions
+// @Description List the content revisions of a course, newest first (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.RevisionResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses/{id}/revisions [get]
+func (h *RevisionHandler) ListCourse(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	revisions, err := h.revisionService.ListCourseRevisions(id, userID.(uuid.UUID), role.(string))
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, revisions)
+}
+
+// @Summary Compare course revisions
+// @Description List the fields that differ between two revisions of a course (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param from query int true "Older revision version"
+// @Param to query int true "Newer revision version"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.RevisionDiffResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffCourse(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	from, to, ok := revisionRange(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.revisionService.DiffCourseRevisions(id, userID.(uuid.UUID), role.(string), from, to)
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, diff)
+}
+
+// @Summary Roll back a course
+// @Description Restore the content of a course from an earlier revision. The rollback is recorded as a new revision. (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Course ID"
+// @Param version path int true "Revision version to restore"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.CourseResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/courses/{id}/revisions/{version}/rollback [post]
+func (h *RevisionHandler) RollbackCourse(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid revision version")
+		return
+	}
+
+	course, err := h.courseService.Rollback(id, userID.(uuid.UUID), role.(string), version, auditRecord(c))
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, course)
+}
+
+// @Summary List lesson revisions
+// @Description List the content revisions of a lesson, newest first (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.RevisionResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons/{id}/revisions [get]
+func (h *RevisionHandler) ListLesson(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	revisions, err := h.revisionService.ListLessonRevisions(id, userID.(uuid.UUID), role.(string))
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, revisions)
+}
+
+// @Summary Compare lesson revisions
+// @Description List the fields that differ between two revisions of a lesson (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Param from query int true "Older revision version"
+// @Param to query int true "Newer revision version"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.RevisionDiffResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffLesson(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	from, to, ok := revisionRange(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.revisionService.DiffLessonRevisions(id, userID.(uuid.UUID), role.(string), from, to)
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, diff)
+}
+
+// @Summary Roll back a lesson
+// @Description Restore the content of a lesson from an earlier revision. The rollback is recorded as a new revision. (admin, or the course instructor)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Lesson ID"
+// @Param version path int true "Revision version to restore"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=services.LessonResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/lessons/{id}/revisions/{version}/rollback [post]
+func (h *RevisionHandler) RollbackLesson(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID")
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid revision version")
+		return
+	}
+
+	lesson, err := h.lessonService.Rollback(id, userID.(uuid.UUID), role.(string), version, auditRecord(c))
+	if err != nil {
+		handleRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, lesson)
+}
+
+// revisionRange parses the from and to revision versions of a diff request
+func revisionRange(c *gin.Context) (int, int, bool) {
+	from, fromErr := strconv.Atoi(c.Query("from"))
+	to, toErr := strconv.Atoi(c.Query("to"))
+	if fromErr != nil || toErr != nil || from < 1 || to < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "from and to must be revision versions")
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// handleRevisionError maps revision errors to HTTP responses
+func handleRevisionError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "course not found", "lesson not found", "revision not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "you are not the instructor of this course":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "order number already exists in this course":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RoleHandler handles role management requests
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler() *RoleHandler {
+	return &RoleHandler{
+		roleService: services.NewRoleService(),
+	}
+}
+
+// @Summary List roles
+// @Description List all roles and the permissions they grant (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]services.RoleResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.roleService.List()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, roles)
+}
+
+// @Summary Grant a role
+// @Description Grant a role to a user. The change is recorded in the user's role history (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body services.GrantRoleRequest true "Role data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.UserResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/users/{id}/roles [post]
+func (h *RoleHandler) Grant(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req services.GrantRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.roleService.Grant(actorID.(uuid.UUID), id, req, auditRecord(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, user)
+}
+
+// @Summary Revoke a role
+// @Description Revoke a role from a user, returning them to the learner role (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Param request body services.RevokeRoleRequest false "Revoke data"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.UserResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/users/{id}/roles/{role} [delete]
+func (h *RoleHandler) Revoke(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	// The body is optional
+	var req services.RevokeRoleRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	user, err := h.roleService.Revoke(actorID.(uuid.UUID), id, c.Param("role"), req, auditRecord(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, user)
+}
+
+// @Summary List role changes
+// @Description List the audited role changes of a user, newest first (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]services.RoleChangeResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/users/{id}/roles/history [get]
+func (h *RoleHandler) ListChanges(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	changes, err := h.roleService.ListChanges(id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, changes)
+}
+
+// handleError maps role service errors to HTTP responses
+func (h *RoleHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found", "role not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "you cannot change your own role":
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case "user already has this role", "user does not have this role", "cannot revoke the default role":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TrashHandler handles deleted courses, lessons, users and categories
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler() *TrashHandler {
+	return &TrashHandler{
+		trashService: services.NewTrashService(),
+	}
+}
+
+// @Summary List deleted records
+// @Description List deleted records of a type, most recently deleted first. Deleted records are purged for good after the retention period.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param type query string true "Record type (course, lesson, user, category)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 10)"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]services.TrashItemResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/trash [get]
+func (h *TrashHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	items, total, err := h.trashService.List(c.Query("type"), page, pageSize)
+	if err != nil {
+		handleTrashError(c, err)
+		return
+	}
+
+	// Set pagination headers
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Page", strconv.Itoa(page))
+	c.Header("X-Page-Size", strconv.Itoa(pageSize))
+
+	utils.SuccessResponse(c, items)
+}
+
+// @Summary Restore a deleted record
+// @Description Restore a deleted record. A course comes back with the lessons deleted with it, and with its enrollments, progress and reviews.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param type path string true "Record type (course, lesson, user, category)"
+// @Param id path string true "Record ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /admin/trash/{type}/{id}/restore [post]
+func (h *TrashHandler) Restore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.trashService.Restore(c.Param("type"), id, auditRecord(c)); err != nil {
+		handleTrashError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "restored successfully"})
+}
+
+// handleTrashError maps trash service errors to HTTP responses
+func handleTrashError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found in trash"):
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case err.Error() == "invalid trash type":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	case strings.HasSuffix(err.Error(), "already exists"),
+		err.Error() == "the course of this lesson is deleted, restore the course first":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -90,7 +90,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Update(id, req)
+	user, err := h.userService.Update(id, req, auditRecord(c))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -140,7 +140,7 @@ func (h *UserHandler) List(c *gin.Context) {
 
 // Delete handles the delete user request
 // @Summary Delete a user
-// @Description Delete a user (admin only)
+// @Description Move a user to the trash (admin only)
 // @Tags users
 // @Accept json
 // @Produce json
@@ -164,7 +164,7 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Delete(id); err != nil {
+	if err := h.userService.Delete(id, auditRecord(c)); err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
 	}
@@ -172,6 +172,33 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	utils.SuccessResponse(c, gin.H{"message": "user deleted successfully"})
 }
 
+// Unlock handles the unlock user request
+// @Summary Unlock a user
+// @Description Clear the failed login attempts and lockout of a user (admin only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} utils.Response{data=object{message=string}} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 403 {object} utils.Response "Forbidden"
+// @Security BearerAuth
+// @Router /users/{id}/unlock [post]
+func (h *UserHandler) Unlock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid user ID")
+		return
+	}
+
+	if err := h.userService.Unlock(id, auditRecord(c)); err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "user unlocked successfully"})
+}
+
 // GetProfile handles the get profile request
 // @Summary Get current user profile
 // @Description Get the profile of the currently authenticated user
@@ -216,7 +243,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Update(id, req)
+	user, err := h.userService.Update(id, req, auditRecord(c))
 	if err != nil {
 		utils.ErrorResponse(c, 400, err.Error())
 		return
@@ -224,3 +251,34 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	utils.SuccessResponse(c, user)
 }
+
+// UpdatePassword handles the change password request
+// @Summary Change current user password
+// @Description Change the password of the currently authenticated user. The current password is required and every other session is signed out. Wrong current passwords are throttled like failed logins.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.ChangePasswordRequest true "Change Password Request"
+// @Success 200 {object} utils.Response{data=object{message=string}} "Success"
+// @Failure 400 {object} utils.Response "Bad Request"
+// @Failure 429 {object} utils.Response "Too Many Requests"
+// @Security BearerAuth
+// @Router /users/me/password [patch]
+func (h *UserHandler) UpdatePassword(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id := userID.(uuid.UUID)
+	sessionID, _ := c.Get("session_id")
+
+	var req services.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	if err := h.userService.ChangePassword(id, sessionID.(uuid.UUID), req); err != nil {
+		utils.ErrorResponse(c, authErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "password changed successfully"})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/services"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WalletHandler handles linked wallet requests
+type WalletHandler struct {
+	walletService *services.WalletService
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler() *WalletHandler {
+	return &WalletHandler{
+		walletService: services.NewWalletService(),
+	}
+}
+
+// @Summary List my wallets
+// @Description List the wallets linked to the authenticated user, primary first
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]services.WalletResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/wallets [get]
+func (h *WalletHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	wallets, err := h.walletService.List(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, wallets)
+}
+
+// @Summary Get a wallet link challenge
+// @Description Issue a nonce to embed in an EIP-4361 message signed by the wallet to link
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.NonceResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/wallets/challenge [post]
+func (h *WalletHandler) Challenge(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	challenge, err := h.walletService.Challenge(userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, challenge)
+}
+
+// @Summary Link a wallet
+// @Description Link the wallet that signed the challenge message to the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param request body services.LinkWalletRequest true "Link Wallet Request"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=services.WalletResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/wallets [post]
+func (h *WalletHandler) Link(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req services.LinkWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wallet, err := h.walletService.Link(userID.(uuid.UUID), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, wallet)
+}
+
+// @Summary Unlink a wallet
+// @Description Remove a wallet from the authenticated user
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param id path string true "Wallet ID"
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /users/me/wallets/{id} [delete]
+func (h *WalletHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	if err := h.walletService.Delete(userID.(uuid.UUID), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, nil)
+}
+
+// handleError maps wallet service errors to HTTP responses
+func (h *WalletHandler) handleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "wallet not found":
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case "wallet is already linked to your account", "wallet is already linked to another account":
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case "invalid SIWE message", "unsupported SIWE message version", "message domain does not match",
+		"chain ID is not supported", "message is not valid yet", "message has expired", "invalid signature",
+		"signature does not match address", "invalid or expired nonce",
+		"cannot remove the only sign-in method of this account":
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -18,6 +18,10 @@ func RegisterRoutes(router *gin.Engine) {
 
 	// Auth routes - public endpoints
 	authHandler := handlers.NewAuthHandler()
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	auth := v1.Group("/auth")
 	auth.Use(authRateLimiter)
 	{
@@ -27,6 +31,18 @@ func RegisterRoutes(router *gin.Engine) {
 		auth.POST("/logout", authHandler.Logout)
 		auth.POST("/forgot-password", authHandler.ForgotPassword)
 		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/magic-link", authHandler.RequestMagicLink)
+		auth.POST("/magic-link/verify", authHandler.VerifyMagicLink)
+		auth.POST("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/resend-verification", authHandler.ResendVerification)
+		auth.GET("/siwe/nonce", authHandler.SIWENonce)
+		auth.POST("/siwe/verify", authHandler.SIWEVerify)
+		auth.POST("/2fa/verify", authHandler.VerifyMFA)
+		auth.POST("/passkeys/challenge", authHandler.PasskeyChallenge)
+		auth.POST("/passkeys/verify", authHandler.PasskeyVerify)
+		auth.GET("/oidc/providers", authHandler.OIDCProviders)
+		auth.GET("/oidc/:provider/authorize", authHandler.OIDCAuthorize)
+		auth.POST("/oidc/:provider/callback", authHandler.OIDCCallback)
 	}
 
 	// Protected routes
@@ -35,6 +51,12 @@ func RegisterRoutes(router *gin.Engine) {
 	{
 		// User routes
 		userHandler := handlers.NewUserHandler()
+		enrollmentHandler := handlers.NewEnrollmentHandler()
+		sessionHandler := handlers.NewSessionHandler()
+		walletHandler := handlers.NewWalletHandler()
+		mfaHandler := handlers.NewMFAHandler()
+		identityHandler := handlers.NewIdentityHandler()
+		passkeyHandler := handlers.NewPasskeyHandler()
 
 		// User profile routes
 		users := protected.Group("/users")
@@ -42,15 +64,50 @@ func RegisterRoutes(router *gin.Engine) {
 			// Current user profile
 			users.GET("/me", userHandler.GetProfile)
 			users.PUT("/me", userHandler.UpdateProfile)
-			// TODO: Implement these endpoints
-			// users.PATCH("/me/password", userHandler.UpdatePassword)
-			// users.GET("/me/enrollments", userHandler.GetEnrollments)
+			users.PATCH("/me/password", userHandler.UpdatePassword)
+			users.GET("/me/enrollments", enrollmentHandler.List)
+			users.GET("/me/sessions", sessionHandler.ListMine)
+			users.DELETE("/me/sessions", sessionHandler.RevokeAllMine)
+			users.DELETE("/me/sessions/:id", sessionHandler.RevokeMine)
+			users.GET("/me/wallets", walletHandler.List)
+			users.POST("/me/wallets", walletHandler.Link)
+			users.POST("/me/wallets/challenge", walletHandler.Challenge)
+			users.DELETE("/me/wallets/:id", walletHandler.Delete)
+			users.POST("/me/2fa/setup", mfaHandler.Setup)
+			users.POST("/me/2fa/enable", mfaHandler.Enable)
+			users.POST("/me/2fa/disable", mfaHandler.Disable)
+			users.POST("/me/2fa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+			users.GET("/me/identities", identityHandler.List)
+			users.POST("/me/identities/:provider/authorize", identityHandler.Authorize)
+			users.POST("/me/identities/:provider", identityHandler.Link)
+			users.DELETE("/me/identities/:id", identityHandler.Delete)
+			users.GET("/me/passkeys", passkeyHandler.List)
+			users.POST("/me/passkeys", passkeyHandler.Register)
+			users.POST("/me/passkeys/challenge", passkeyHandler.Challenge)
+			users.PATCH("/me/passkeys/:id", passkeyHandler.Rename)
+			users.DELETE("/me/passkeys/:id", passkeyHandler.Delete)
 
 			// Admin routes for user management
-			users.GET("", middleware.RoleMiddleware("admin"), userHandler.List)
-			users.GET("/:id", middleware.RoleMiddleware("admin"), userHandler.Get)
-			users.PUT("/:id", middleware.RoleMiddleware("admin"), userHandler.Update)
-			users.DELETE("/:id", middleware.RoleMiddleware("admin"), userHandler.Delete)
+			auditUsers := middleware.AuditMiddleware("user")
+			users.GET("", middleware.RequirePermission("user:read"), userHandler.List)
+			users.GET("/:id", middleware.RequirePermission("user:read"), userHandler.Get)
+			users.PUT("/:id", auditUsers, middleware.RequirePermission("user:write"), userHandler.Update)
+			users.DELETE("/:id", auditUsers, middleware.RequirePermission("user:delete"), userHandler.Delete)
+			users.POST("/:id/unlock", auditUsers, middleware.RequirePermission("user:write"), userHandler.Unlock)
+			users.GET("/:id/sessions", middleware.RequirePermission("user:read"), sessionHandler.List)
+			users.DELETE("/:id/sessions", auditUsers, middleware.RequirePermission("user:write"), sessionHandler.RevokeAll)
+			users.DELETE("/:id/sessions/:session_id", auditUsers, middleware.RequirePermission("user:write"), sessionHandler.Revoke)
+		}
+
+		// Admin role management routes
+		roleHandler := handlers.NewRoleHandler()
+		adminRoles := protected.Group("/admin")
+		adminRoles.Use(middleware.AuditMiddleware("user"), middleware.RequirePermission("role:manage"))
+		{
+			adminRoles.GET("/roles", roleHandler.List)
+			adminRoles.GET("/users/:id/roles/history", roleHandler.ListChanges)
+			adminRoles.POST("/users/:id/roles", roleHandler.Grant)
+			adminRoles.DELETE("/users/:id/roles/:role", roleHandler.Revoke)
 		}
 
 		// Category routes
@@ -63,7 +120,7 @@ func RegisterRoutes(router *gin.Engine) {
 
 		// Admin category routes
 		adminCategories := protected.Group("/admin/categories")
-		adminCategories.Use(middleware.RoleMiddleware("admin"))
+		adminCategories.Use(middleware.AuditMiddleware("category"), middleware.RequirePermission("category:write"))
 		{
 			adminCategories.POST("", categoryHandler.Create)
 			adminCategories.PUT("/:id", categoryHandler.Update)
@@ -76,54 +133,102 @@ func RegisterRoutes(router *gin.Engine) {
 		{
 			courses.GET("", courseHandler.List)
 			courses.GET("/featured", courseHandler.GetFeatured)
-			courses.GET("/:id", courseHandler.Get)
-			courses.GET("/:id/lessons", courseHandler.GetLessons)
-			// courses.GET("/:id/reviews", courseHandler.GetReviews)
+			courses.GET("/:id", middleware.OptionalAuthMiddleware(), courseHandler.Get)
+			courses.GET("/:id/lessons", middleware.OptionalAuthMiddleware(), courseHandler.GetLessons)
+			courses.GET("/:id/reviews", middleware.OptionalAuthMiddleware(), courseHandler.GetReviews)
 		}
 
 		// Protected course routes
 		protectedCourses := protected.Group("/courses")
 		{
 			protectedCourses.POST("/:id/enroll", courseHandler.Enroll)
-			// protectedCourses.POST("/:id/reviews", courseHandler.AddReview)
+			protectedCourses.GET("/:id/progress", courseHandler.GetProgress)
+			protectedCourses.GET("/:id/enrollments", middleware.RequirePermission("enrollment:read"), enrollmentHandler.ListByCourse)
+			protectedCourses.GET("/:id/enrollments/export", middleware.RequirePermission("enrollment:read"), enrollmentHandler.ExportRoster)
+			protectedCourses.POST("/:id/reviews", courseHandler.AddReview)
+		}
+
+		// Review routes
+		reviewHandler := handlers.NewReviewHandler()
+		reviews := protected.Group("/reviews")
+		{
+			reviews.PUT("/:id", reviewHandler.Update)
+			reviews.DELETE("/:id", reviewHandler.Delete)
+			reviews.POST("/:id/reply", reviewHandler.Reply)
 		}
 
+		// Admin review routes
+		adminReviews := protected.Group("/admin/reviews")
+		adminReviews.Use(middleware.AuditMiddleware("review"), middleware.RequirePermission("review:moderate"))
+		{
+			adminReviews.PUT("/:id/moderation", reviewHandler.Moderate)
+		}
+
+		// Revision routes
+		revisionHandler := handlers.NewRevisionHandler()
+
 		// Admin course routes
 		adminCourses := protected.Group("/admin/courses")
-		adminCourses.Use(middleware.RoleMiddleware("admin"))
+		adminCourses.Use(middleware.AuditMiddleware("course"), middleware.RequirePermission("course:write"))
 		{
+			adminCourses.GET("", courseHandler.ListManaged)
 			adminCourses.POST("", courseHandler.Create)
 			adminCourses.PUT("/:id", courseHandler.Update)
 			adminCourses.DELETE("/:id", courseHandler.Delete)
+			adminCourses.PUT("/:id/status", courseHandler.ChangeStatus)
+			adminCourses.GET("/:id/status/history", courseHandler.GetStatusHistory)
+			adminCourses.GET("/:id/revisions", revisionHandler.ListCourse)
+			adminCourses.GET("/:id/revisions/diff", revisionHandler.DiffCourse)
+			adminCourses.POST("/:id/revisions/:version/rollback", revisionHandler.RollbackCourse)
 		}
 
 		// Lesson routes
-		// lessonHandler := handlers.NewLessonHandler()
-		// lessons := protected.Group("/lessons")
-		// {
-		//     lessons.GET("/:id", lessonHandler.Get)
-		//     lessons.GET("/:id/progress", lessonHandler.GetProgress)
-		//     lessons.POST("/:id/progress", lessonHandler.UpdateProgress)
-		//     lessons.POST("/:id/complete", lessonHandler.Complete)
-		// }
-		//
-		// // Admin lesson routes
-		// adminLessons := protected.Group("/admin/lessons")
-		// adminLessons.Use(middleware.RoleMiddleware("admin", "instructor"))
-		// {
-		//     adminLessons.POST("", lessonHandler.Create)
-		//     adminLessons.PUT("/:id", lessonHandler.Update)
-		//     adminLessons.DELETE("/:id", lessonHandler.Delete)
-		// }
+		lessonHandler := handlers.NewLessonHandler()
+		lessons := protected.Group("/lessons")
+		{
+			lessons.GET("/:id", lessonHandler.Get)
+			lessons.GET("/:id/progress", lessonHandler.GetProgress)
+			lessons.POST("/:id/progress", lessonHandler.UpdateProgress)
+			lessons.POST("/:id/complete", lessonHandler.Complete)
+		}
+
+		// Admin lesson routes
+		adminLessons := protected.Group("/admin/lessons")
+		adminLessons.Use(middleware.AuditMiddleware("lesson"), middleware.RequirePermission("lesson:write"))
+		{
+			adminLessons.POST("", lessonHandler.Create)
+			adminLessons.PUT("/:id", lessonHandler.Update)
+			adminLessons.DELETE("/:id", lessonHandler.Delete)
+			adminLessons.GET("/:id/revisions", revisionHandler.ListLesson)
+			adminLessons.GET("/:id/revisions/diff", revisionHandler.DiffLesson)
+			adminLessons.POST("/:id/revisions/:version/rollback", revisionHandler.RollbackLesson)
+		}
+
+		// Trash routes
+		trashHandler := handlers.NewTrashHandler()
+		adminTrash := protected.Group("/admin/trash")
+		adminTrash.Use(middleware.AuditMiddleware(""), middleware.RequirePermission("trash:manage"))
+		{
+			adminTrash.GET("", trashHandler.List)
+			adminTrash.POST("/:type/:id/restore", trashHandler.Restore)
+		}
+
+		// Audit log routes
+		auditHandler := handlers.NewAuditHandler()
+		adminAudit := protected.Group("/admin/audit")
+		adminAudit.Use(middleware.RequirePermission("audit:read"))
+		{
+			adminAudit.GET("", auditHandler.List)
+			adminAudit.GET("/export", auditHandler.Export)
+		}
 
 		// Enrollment routes
-		// enrollmentHandler := handlers.NewEnrollmentHandler()
-		// enrollments := protected.Group("/enrollments")
-		// {
-		//     enrollments.GET("", enrollmentHandler.List)
-		//     enrollments.POST("", enrollmentHandler.Create)
-		//     enrollments.DELETE("/:id", enrollmentHandler.Delete)
-		// }
+		enrollments := protected.Group("/enrollments")
+		{
+			enrollments.GET("", enrollmentHandler.List)
+			enrollments.POST("", enrollmentHandler.Create)
+			enrollments.DELETE("/:id", enrollmentHandler.Delete)
+		}
 
 		// Progress routes
 		// progressHandler := handlers.NewProgressHandler()
@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -43,6 +45,17 @@ func (c *Cache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
 }
 
+// DeleteByPrefix deletes every key starting with the prefix
+func (c *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	iter := c.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
 // SetUserSession sets a user session in the cache
 func (c *Cache) SetUserSession(ctx context.Context, sessionID string, userData interface{}, expiration time.Duration) error {
 	key := "user:session:" + sessionID
@@ -102,22 +115,109 @@ func (c *Cache) GetCategories(ctx context.Context, dest interface{}) error {
 	return c.GetJSON(ctx, "categories:all", dest)
 }
 
-// SetUserProgress sets a user's progress for a course in the cache
-func (c *Cache) SetUserProgress(ctx context.Context, userID, courseID string, progressData interface{}, expiration time.Duration) error {
-	key := "user:" + userID + ":progress:" + courseID
-	return c.SetJSON(ctx, key, progressData, expiration)
+// userProgressKey is the hash of a user's buffered progress in a course, with
+// one field per lesson
+func userProgressKey(userID, courseID string) string {
+	return "user:" + userID + ":progress:" + courseID + ":lessons"
 }
 
-// GetUserProgress gets a user's progress for a course from the cache
-func (c *Cache) GetUserProgress(ctx context.Context, userID, courseID string, dest interface{}) error {
-	key := "user:" + userID + ":progress:" + courseID
-	return c.GetJSON(ctx, key, dest)
+// progressDirtyKey is the sorted set of buffered lesson progress that has not
+// been written to the database yet, scored by the time it was marked
+const progressDirtyKey = "progress:dirty"
+
+// clearDirtyProgressScript removes a dirty mark unless a newer heartbeat has
+// marked the lesson again since it was read
+var clearDirtyProgressScript = redis.NewScript(`
+if tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1])) == tonumber(ARGV[2]) then
+	return redis.call('ZREM', KEYS[1], ARGV[1])
+end
+return 0`)
+
+// DirtyProgress is the buffered progress of a user for a lesson that has to be
+// written to the database
+type DirtyProgress struct {
+	UserID   string
+	CourseID string
+	LessonID string
+	MarkedAt int64
+}
+
+// member is the sorted set member of the dirty mark
+func (p DirtyProgress) member() string {
+	return p.UserID + ":" + p.CourseID + ":" + p.LessonID
+}
+
+// SetLessonProgress sets a user's progress for a lesson of a course in the
+// cache. Every lesson is a field of its own, so heartbeats for different
+// lessons do not overwrite each other.
+func (c *Cache) SetLessonProgress(ctx context.Context, userID, courseID, lessonID string, progressData interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(progressData)
+	if err != nil {
+		return err
+	}
+
+	key := userProgressKey(userID, courseID)
+	pipe := c.client.TxPipeline()
+	pipe.HSet(ctx, key, lessonID, data)
+	pipe.Expire(ctx, key, expiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetLessonProgress gets a user's progress for a lesson from the cache. It
+// reports false if the lesson has no buffered progress.
+func (c *Cache) GetLessonProgress(ctx context.Context, userID, courseID, lessonID string, dest interface{}) (bool, error) {
+	data, err := c.client.HGet(ctx, userProgressKey(userID, courseID), lessonID).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, dest)
+}
+
+// GetUserProgress gets a user's progress for every buffered lesson of a course
+// from the cache, as JSON keyed by lesson ID
+func (c *Cache) GetUserProgress(ctx context.Context, userID, courseID string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, userProgressKey(userID, courseID)).Result()
 }
 
 // DeleteUserProgress deletes a user's progress for a course from the cache
 func (c *Cache) DeleteUserProgress(ctx context.Context, userID, courseID string) error {
-	key := "user:" + userID + ":progress:" + courseID
-	return c.Delete(ctx, key)
+	return c.Delete(ctx, userProgressKey(userID, courseID))
+}
+
+// MarkProgressDirty marks a user's buffered progress for a lesson to be
+// written to the database
+func (c *Cache) MarkProgressDirty(ctx context.Context, userID, courseID, lessonID string, at time.Time) error {
+	p := DirtyProgress{UserID: userID, CourseID: courseID, LessonID: lessonID}
+	return c.client.ZAdd(ctx, progressDirtyKey, redis.Z{Score: float64(at.UnixMilli()), Member: p.member()}).Err()
+}
+
+// GetDirtyProgress lists the buffered progress marked to be written to the database
+func (c *Cache) GetDirtyProgress(ctx context.Context) ([]DirtyProgress, error) {
+	marks, err := c.client.ZRangeWithScores(ctx, progressDirtyKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dirty := make([]DirtyProgress, 0, len(marks))
+	for _, mark := range marks {
+		member, _ := mark.Member.(string)
+		ids := strings.Split(member, ":")
+		if len(ids) != 3 {
+			continue
+		}
+		dirty = append(dirty, DirtyProgress{UserID: ids[0], CourseID: ids[1], LessonID: ids[2], MarkedAt: int64(mark.Score)})
+	}
+	return dirty, nil
+}
+
+// ClearDirtyProgress removes the mark of buffered progress that has been
+// written to the database, unless it was marked again in the meantime
+func (c *Cache) ClearDirtyProgress(ctx context.Context, p DirtyProgress) error {
+	return clearDirtyProgressScript.Run(ctx, c.client, []string{progressDirtyKey}, p.member(), p.MarkedAt).Err()
 }
 
 // IncrementRateLimit increments the rate limit counter for an IP
@@ -142,3 +242,92 @@ func (c *Cache) GetRateLimit(ctx context.Context, ip string) (int64, error) {
 	}
 	return val, err
 }
+
+// DenyToken adds an access token ID to the denylist
+func (c *Cache) DenyToken(ctx context.Context, tokenID string, expiration time.Duration) error {
+	return c.client.Set(ctx, "token:denylist:"+tokenID, 1, expiration).Err()
+}
+
+// DenySession adds a session ID to the denylist, revoking every access token issued for it
+func (c *Cache) DenySession(ctx context.Context, sessionID string, expiration time.Duration) error {
+	return c.client.Set(ctx, "session:denylist:"+sessionID, 1, expiration).Err()
+}
+
+// SetTokensValidAfter sets the time before which access tokens of a user are invalid
+func (c *Cache) SetTokensValidAfter(ctx context.Context, userID string, validAfter time.Time, expiration time.Duration) error {
+	return c.client.Set(ctx, "user:"+userID+":tokens_valid_after", validAfter.Unix(), expiration).Err()
+}
+
+// GetTokenRevocation looks up the denylist entries of an access token and the
+// validity watermark of its user in a single round trip
+func (c *Cache) GetTokenRevocation(ctx context.Context, tokenID, sessionID, userID string) (bool, time.Time, error) {
+	values, err := c.client.MGet(ctx,
+		"token:denylist:"+tokenID,
+		"session:denylist:"+sessionID,
+		"user:"+userID+":tokens_valid_after",
+	).Result()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	denied := values[0] != nil || values[1] != nil
+
+	var validAfter time.Time
+	if s, ok := values[2].(string); ok {
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		validAfter = time.Unix(unix, 0)
+	}
+
+	return denied, validAfter, nil
+}
+
+// SetNonce stores a single-use nonce for a purpose such as "siwe"
+func (c *Cache) SetNonce(ctx context.Context, purpose, nonce string, value interface{}, expiration time.Duration) error {
+	return c.SetJSON(ctx, "nonce:"+purpose+":"+nonce, value, expiration)
+}
+
+// ConsumeNonce loads and deletes a nonce. It returns redis.Nil if the nonce
+// does not exist, has expired or was already used.
+func (c *Cache) ConsumeNonce(ctx context.Context, purpose, nonce string, dest interface{}) error {
+	data, err := c.client.GetDel(ctx, "nonce:"+purpose+":"+nonce).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// PeekNonce loads a nonce without consuming it
+func (c *Cache) PeekNonce(ctx context.Context, purpose, nonce string, dest interface{}) error {
+	return c.GetJSON(ctx, "nonce:"+purpose+":"+nonce, dest)
+}
+
+// IncrementCounter increments a counter that expires after the given duration
+func (c *Cache) IncrementCounter(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	pipe := c.client.Pipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// SetOnce sets a marker key. It returns false if the key already existed.
+func (c *Cache) SetOnce(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, 1, expiration).Result()
+}
+
+// TTL returns the remaining time to live of a key, or zero if it does not exist
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEvent records a privileged action: who did what to which record, how
+// the record looked before and after, and where the request came from.
+// Changes are recorded in the transaction that makes them and have no status;
+// other requests are recorded with their response status once handled.
+// Audit events are append-only.
+type AuditEvent struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    *uuid.UUID      `gorm:"type:uuid" json:"actor_id,omitempty"`
+	ActorRole  string          `gorm:"size:50" json:"actor_role,omitempty"`
+	Action     string          `gorm:"size:100;not null" json:"action"`
+	TargetType string          `gorm:"size:50" json:"target_type,omitempty"`
+	TargetID   *uuid.UUID      `gorm:"type:uuid" json:"target_id,omitempty"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before,omitempty"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after,omitempty"`
+	Method     string          `gorm:"size:10;not null" json:"method"`
+	Path       string          `gorm:"size:255;not null" json:"path"`
+	Status     *int            `json:"status,omitempty"`
+	IPAddress  string          `gorm:"size:45" json:"ip_address,omitempty"`
+	UserAgent  string          `gorm:"type:text" json:"user_agent,omitempty"`
+	RequestID  string          `gorm:"size:100" json:"request_id,omitempty"`
+	CreatedAt  time.Time       `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the AuditEvent model
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Types of records changed by audited actions
+const (
+	AuditTargetCourse   = "course"
+	AuditTargetLesson   = "lesson"
+	AuditTargetUser     = "user"
+	AuditTargetCategory = "category"
+	AuditTargetReview   = "review"
+)
+
+// Actions recorded by services. Requests without a service action are
+// recorded with their method and route, e.g. "DELETE /api/v1/users/:id/sessions".
+const (
+	AuditActionCourseCreate       = "course.create"
+	AuditActionCourseUpdate       = "course.update"
+	AuditActionCourseDelete       = "course.delete"
+	AuditActionCourseStatusChange = "course.status_change"
+	AuditActionCourseRollback     = "course.rollback"
+	AuditActionLessonCreate       = "lesson.create"
+	AuditActionLessonUpdate       = "lesson.update"
+	AuditActionLessonDelete       = "lesson.delete"
+	AuditActionLessonRollback     = "lesson.rollback"
+	AuditActionCategoryCreate     = "category.create"
+	AuditActionCategoryUpdate     = "category.update"
+	AuditActionCategoryDelete     = "category.delete"
+	AuditActionUserUpdate         = "user.update"
+	AuditActionUserDelete         = "user.delete"
+	AuditActionUserUnlock         = "user.unlock"
+	AuditActionRoleGrant          = "user.role_grant"
+	AuditActionRoleRevoke         = "user.role_revoke"
+	AuditActionReviewModerate     = "review.moderate"
+	AuditActionRestore            = "trash.restore"
+)
@@ -8,13 +8,14 @@ import (
 )
 
 type Category struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Name        string    `gorm:"size:100;not null;unique" json:"name"`
-	Description string    `gorm:"type:text" json:"description,omitempty"`
-	Slug        string    `gorm:"size:100;not null;unique" json:"slug"`
-	CreatedAt   time.Time `gorm:"default:now()" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"default:now()" json:"updated_at"`
-	Courses     []Course  `gorm:"many2many:course_categories;" json:"courses,omitempty"`
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string         `gorm:"size:100;not null;unique" json:"name"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	Slug        string         `gorm:"size:100;not null;unique" json:"slug"`
+	CreatedAt   time.Time      `gorm:"default:now()" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"default:now()" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	Courses     []Course       `gorm:"many2many:course_categories;" json:"courses,omitempty"`
 }
 
 // TableName specifies the table name for the Category model
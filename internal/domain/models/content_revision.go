@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Revisioned content types
+const (
+	RevisionEntityCourse = "course"
+	RevisionEntityLesson = "lesson"
+)
+
+// Revision actions
+const (
+	RevisionActionCreate   = "create"
+	RevisionActionUpdate   = "update"
+	RevisionActionRollback = "rollback"
+)
+
+// ContentRevision is an immutable snapshot of the content of a course or lesson
+// after a change. Versions count up from 1 per course or lesson.
+type ContentRevision struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EntityType      string          `gorm:"size:20;not null" json:"entity_type"`
+	EntityID        uuid.UUID       `gorm:"type:uuid;not null" json:"entity_id"`
+	Version         int             `gorm:"not null" json:"version"`
+	Action          string          `gorm:"size:20;not null" json:"action"`
+	RestoredVersion *int            `json:"restored_version,omitempty"`
+	Snapshot        json.RawMessage `gorm:"type:jsonb;not null" json:"snapshot"`
+	AuthorID        *uuid.UUID      `gorm:"type:uuid" json:"author_id,omitempty"`
+	CreatedAt       time.Time       `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the ContentRevision model
+func (ContentRevision) TableName() string {
+	return "content_revisions"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *ContentRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
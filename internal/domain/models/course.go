@@ -7,20 +7,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// Course statuses. New courses start as drafts and are listed once an admin
+// publishes them. Archived courses are no longer listed or open for enrollment,
+// but their enrolled learners keep access.
+const (
+	CourseStatusDraft     = "draft"
+	CourseStatusReview    = "review"
+	CourseStatusPublished = "published"
+	CourseStatusArchived  = "archived"
+)
+
 type Course struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title        string    `gorm:"size:255;not null" json:"title"`
-	Description  string    `gorm:"type:text" json:"description,omitempty"`
-	Thumbnail    string    `gorm:"size:255" json:"thumbnail,omitempty"`
-	InstructorID uuid.UUID `gorm:"type:uuid" json:"instructor_id"`
-	Instructor   User      `gorm:"foreignKey:InstructorID" json:"instructor,omitempty"`
-	Price        float64   `gorm:"type:decimal(10,2)" json:"price"`
-	Level        string    `gorm:"size:50" json:"level,omitempty"` // beginner, intermediate, advanced
-	Duration     int       `json:"duration,omitempty"`             // total minutes
-	Category     string    `gorm:"size:100" json:"category,omitempty"`
-	CreatedAt    time.Time `gorm:"default:now()" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"default:now()" json:"updated_at"`
-	Lessons      []Lesson  `gorm:"foreignKey:CourseID" json:"lessons,omitempty"`
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title        string         `gorm:"size:255;not null" json:"title"`
+	Description  string         `gorm:"type:text" json:"description,omitempty"`
+	Thumbnail    string         `gorm:"size:255" json:"thumbnail,omitempty"`
+	InstructorID uuid.UUID      `gorm:"type:uuid" json:"instructor_id"`
+	Instructor   User           `gorm:"foreignKey:InstructorID" json:"instructor,omitempty"`
+	Price        float64        `gorm:"type:decimal(10,2)" json:"price"`
+	Level        string         `gorm:"size:50" json:"level,omitempty"` // beginner, intermediate, advanced
+	Duration     int            `json:"duration,omitempty"`             // total minutes
+	Category     string         `gorm:"size:100" json:"category,omitempty"`
+	RatingAvg    float64        `gorm:"type:decimal(3,2);not null;default:0" json:"rating_avg"`
+	RatingCount  int            `gorm:"not null;default:0" json:"rating_count"`
+	Status       string         `gorm:"size:20;not null;default:draft" json:"status"`
+	PublishedAt  *time.Time     `json:"published_at,omitempty"`
+	CreatedAt    time.Time      `gorm:"default:now()" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"default:now()" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	Lessons      []Lesson       `gorm:"foreignKey:CourseID" json:"lessons,omitempty"`
 }
 
 // TableName specifies the table name for the Course model
@@ -35,3 +50,27 @@ func (c *Course) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// CourseStatusChange records a change of a course's publishing status
+type CourseStatusChange struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID  uuid.UUID  `gorm:"type:uuid" json:"course_id"`
+	OldStatus string     `gorm:"size:20;not null" json:"old_status"`
+	NewStatus string     `gorm:"size:20;not null" json:"new_status"`
+	ChangedBy *uuid.UUID `gorm:"type:uuid" json:"changed_by,omitempty"`
+	Comment   string     `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the CourseStatusChange model
+func (CourseStatusChange) TableName() string {
+	return "course_status_changes"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *CourseStatusChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
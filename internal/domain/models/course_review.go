@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CourseReview is a learner's rating and review of a course they are enrolled in
+type CourseReview struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID     uuid.UUID  `gorm:"type:uuid;not null" json:"course_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	User         User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Rating       int        `gorm:"not null" json:"rating"` // 1 to 5 stars
+	Comment      string     `gorm:"type:text" json:"comment,omitempty"`
+	Reply        string     `gorm:"type:text" json:"reply,omitempty"`
+	RepliedBy    *uuid.UUID `gorm:"type:uuid" json:"replied_by,omitempty"`
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+	HiddenBy     *uuid.UUID `gorm:"type:uuid" json:"hidden_by,omitempty"`
+	HiddenReason string     `gorm:"type:text" json:"hidden_reason,omitempty"`
+	CreatedAt    time.Time  `gorm:"default:now()" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"default:now()" json:"updated_at"`
+}
+
+// TableName specifies the table name for the CourseReview model
+func (CourseReview) TableName() string {
+	return "course_reviews"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *CourseReview) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -8,16 +8,17 @@ import (
 )
 
 type Lesson struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	CourseID    uuid.UUID `gorm:"type:uuid" json:"course_id"`
-	Title       string    `gorm:"size:255;not null" json:"title"`
-	Description string    `gorm:"type:text" json:"description,omitempty"`
-	VideoURL    string    `gorm:"size:255;not null" json:"video_url"`
-	VideoID     string    `gorm:"size:100;not null" json:"video_id"`
-	Duration    int       `json:"duration,omitempty"` // minutes
-	OrderNumber int       `gorm:"not null" json:"order_number"`
-	CreatedAt   time.Time `gorm:"default:now()" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"default:now()" json:"updated_at"`
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID    uuid.UUID      `gorm:"type:uuid" json:"course_id"`
+	Title       string         `gorm:"size:255;not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	VideoURL    string         `gorm:"size:255;not null" json:"video_url"`
+	VideoID     string         `gorm:"size:100;not null" json:"video_id"`
+	Duration    int            `json:"duration,omitempty"` // minutes
+	OrderNumber int            `gorm:"not null" json:"order_number"`
+	CreatedAt   time.Time      `gorm:"default:now()" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"default:now()" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName specifies the table name for the Lesson model
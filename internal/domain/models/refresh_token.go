@@ -8,12 +8,20 @@ import (
 )
 
 type RefreshToken struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid" json:"user_id"`
-	User      User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Token     string    `gorm:"size:255;not null;unique" json:"token"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid" json:"user_id"`
+	User        User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TokenHash   string     `gorm:"size:255;not null;unique" json:"-"`
+	FamilyID    uuid.UUID  `gorm:"type:uuid;not null" json:"family_id"`
+	ParentID    *uuid.UUID `gorm:"type:uuid" json:"parent_id,omitempty"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	UserAgent   string     `gorm:"type:text" json:"user_agent,omitempty"`
+	IPAddress   string     `gorm:"size:45" json:"ip_address,omitempty"`
+	DeviceLabel string     `gorm:"size:255" json:"device_label,omitempty"`
+	LastUsedAt  time.Time  `gorm:"default:now()" json:"last_used_at"`
+	MFAVerified bool       `gorm:"column:mfa_verified;not null;default:false" json:"mfa_verified"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt   time.Time  `gorm:"default:now()" json:"created_at"`
 }
 
 // TableName specifies the table name for the RefreshToken model
@@ -26,5 +34,8 @@ func (r *RefreshToken) BeforeCreate(tx *gorm.DB) error {
 	if r.ID == uuid.Nil {
 		r.ID = uuid.New()
 	}
+	if r.FamilyID == uuid.Nil {
+		r.FamilyID = r.ID
+	}
 	return nil
 }
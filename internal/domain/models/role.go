@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string       `gorm:"size:50;not null;unique" json:"name"`
+	Description string       `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time    `gorm:"default:now()" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"default:now()" json:"updated_at"`
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+}
+
+// TableName specifies the table name for the Role model
+func (Role) TableName() string {
+	return "roles"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string    `gorm:"size:100;not null;unique" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the Permission model
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *Permission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// RoleChange records a role granted or revoked by an admin
+type RoleChange struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid" json:"user_id"`
+	OldRole   string     `gorm:"size:50;not null" json:"old_role"`
+	NewRole   string     `gorm:"size:50;not null" json:"new_role"`
+	ChangedBy *uuid.UUID `gorm:"type:uuid" json:"changed_by,omitempty"`
+	Reason    string     `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the RoleChange model
+func (RoleChange) TableName() string {
+	return "role_changes"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *RoleChange) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -8,14 +8,19 @@ import (
 )
 
 type User struct {
-	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email          string    `gorm:"size:255;not null;unique" json:"email"`
-	PasswordHash   string    `gorm:"size:255;not null" json:"-"`
-	FullName       string    `gorm:"size:255;not null" json:"full_name"`
-	Role           string    `gorm:"size:50;not null;default:user" json:"role"`
-	ProfilePicture string    `gorm:"size:255" json:"profile_picture,omitempty"`
-	CreatedAt      time.Time `gorm:"default:now()" json:"created_at"`
-	UpdatedAt      time.Time `gorm:"default:now()" json:"updated_at"`
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email           *string        `gorm:"size:255;unique" json:"email,omitempty"`
+	PasswordHash    string         `gorm:"size:255;not null" json:"-"`
+	FullName        string         `gorm:"size:255;not null" json:"full_name"`
+	Role            string         `gorm:"size:50;not null;default:user" json:"role"`
+	ProfilePicture  string         `gorm:"size:255" json:"profile_picture,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	TOTPSecret      string         `gorm:"column:totp_secret;type:text" json:"-"`
+	TOTPEnabledAt   *time.Time     `gorm:"column:totp_enabled_at" json:"-"`
+	CreatedAt       time.Time      `gorm:"default:now()" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"default:now()" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	Wallets         []UserWallet   `gorm:"foreignKey:UserID" json:"wallets,omitempty"`
 }
 
 // TableName specifies the table name for the User model
@@ -23,6 +28,25 @@ func (User) TableName() string {
 	return "users"
 }
 
+// EmailAddress returns the email address of the user, or an empty string for
+// wallet-only accounts
+func (u *User) EmailAddress() string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+// PrimaryWallet returns the primary wallet of the user, if loaded
+func (u *User) PrimaryWallet() *UserWallet {
+	for i := range u.Wallets {
+		if u.Wallets[i].IsPrimary {
+			return &u.Wallets[i]
+		}
+	}
+	return nil
+}
+
 // BeforeCreate will set a UUID rather than numeric ID
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserWallet struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Address    string     `gorm:"size:42;not null;unique" json:"address"`
+	ChainID    int64      `gorm:"not null" json:"chain_id"`
+	Label      string     `gorm:"size:100" json:"label,omitempty"`
+	IsPrimary  bool       `gorm:"not null;default:false" json:"is_primary"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for the UserWallet model
+func (UserWallet) TableName() string {
+	return "user_wallets"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (w *UserWallet) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
@@ -18,9 +18,14 @@ func NewCategoryRepository() *CategoryRepository {
 	}
 }
 
-// Create creates a new category
-func (r *CategoryRepository) Create(category *models.Category) error {
-	return r.db.Create(category).Error
+// Create creates a new category and records the audit event
+func (r *CategoryRepository) Create(category *models.Category, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // GetByID gets a category by ID
@@ -43,14 +48,34 @@ func (r *CategoryRepository) GetBySlug(slug string) (*models.Category, error) {
 	return &category, nil
 }
 
-// Update updates a category
-func (r *CategoryRepository) Update(category *models.Category) error {
-	return r.db.Save(category).Error
+// GetByName gets a category by name
+func (r *CategoryRepository) GetByName(name string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Where("name = ?", name).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Update updates a category and records the audit event
+func (r *CategoryRepository) Update(category *models.Category, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
-// Delete deletes a category
-func (r *CategoryRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Category{}, id).Error
+// Delete deletes a category and records the audit event
+func (r *CategoryRepository) Delete(id uuid.UUID, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Category{}, id).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // List lists all categories
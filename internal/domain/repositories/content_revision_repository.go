@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ContentRevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewContentRevisionRepository creates a new content revision repository
+func NewContentRevisionRepository() *ContentRevisionRepository {
+	return &ContentRevisionRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// GetByEntity gets the revisions of a course or lesson, newest first
+func (r *ContentRevisionRepository) GetByEntity(entityType string, entityID uuid.UUID) ([]models.ContentRevision, error) {
+	var revisions []models.ContentRevision
+	err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, entityID).Order("version DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetByVersion gets one revision of a course or lesson
+func (r *ContentRevisionRepository) GetByVersion(entityType string, entityID uuid.UUID, version int) (*models.ContentRevision, error) {
+	var revision models.ContentRevision
+	err := r.db.Where("entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// createRevision numbers a revision after the latest one of its course or lesson
+// and stores it. Callers write the course or lesson row in the same transaction
+// first, so its row lock keeps concurrent changes from taking the same version.
+func createRevision(tx *gorm.DB, revision *models.ContentRevision) error {
+	var latest int
+	err := tx.Model(&models.ContentRevision{}).
+		Where("entity_type = ? AND entity_id = ?", revision.EntityType, revision.EntityID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	revision.Version = latest + 1
+	return tx.Create(revision).Error
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
 	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/google/uuid"
@@ -18,9 +20,18 @@ func NewCourseRepository() *CourseRepository {
 	}
 }
 
-// Create creates a new course
-func (r *CourseRepository) Create(course *models.Course) error {
-	return r.db.Create(course).Error
+// Create creates a new course and records its first revision and the audit event
+func (r *CourseRepository) Create(course *models.Course, revision *models.ContentRevision, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(course).Error; err != nil {
+			return err
+		}
+		revision.EntityID = course.ID
+		if err := createRevision(tx, revision); err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // GetByID gets a course by ID
@@ -33,7 +44,7 @@ func (r *CourseRepository) GetByID(id uuid.UUID) (*models.Course, error) {
 	return &course, nil
 }
 
-// GetByIDWithLessons gets a course by ID with lessons
+// GetByIDWithLessons gets a course by ID with lessons, whatever its status
 func (r *CourseRepository) GetByIDWithLessons(id uuid.UUID) (*models.Course, error) {
 	var course models.Course
 	err := r.db.Preload("Instructor").Preload("Lessons", func(db *gorm.DB) *gorm.DB {
@@ -45,25 +56,103 @@ func (r *CourseRepository) GetByIDWithLessons(id uuid.UUID) (*models.Course, err
 	return &course, nil
 }
 
-// Update updates a course
-func (r *CourseRepository) Update(course *models.Course) error {
-	return r.db.Save(course).Error
+// GetPublicByIDWithLessons gets a published or archived course by ID with lessons
+func (r *CourseRepository) GetPublicByIDWithLessons(id uuid.UUID) (*models.Course, error) {
+	var course models.Course
+	err := r.db.Preload("Instructor").Preload("Lessons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("order_number ASC")
+	}).Where("id = ? AND status IN ?", id, []string{models.CourseStatusPublished, models.CourseStatusArchived}).First(&course).Error
+	if err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
+// Update updates a course and records the revision of its new content and the
+// audit event. The
+// rating aggregates are maintained by the review repository and the status by
+// UpdateStatus, so they are not overwritten with a possibly stale copy.
+func (r *CourseRepository) Update(course *models.Course, revision *models.ContentRevision, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RatingAvg", "RatingCount", "Status", "PublishedAt").Save(course).Error; err != nil {
+			return err
+		}
+		revision.EntityID = course.ID
+		if err := createRevision(tx, revision); err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
-// Delete deletes a course
-func (r *CourseRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Course{}, id).Error
+// UpdateStatus changes a course's status and records the change and the audit
+// event. A non-nil publishedAt is stored as the publication time.
+func (r *CourseRepository) UpdateStatus(change *models.CourseStatusChange, publishedAt *time.Time, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":     change.NewStatus,
+			"updated_at": time.Now(),
+		}
+		if publishedAt != nil {
+			updates["published_at"] = *publishedAt
+		}
+		result := tx.Model(&models.Course{}).Where("id = ? AND status = ?", change.CourseID, change.OldStatus).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		// Another request changed the status first
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
-// List lists all courses
+// GetStatusChanges gets the status changes of a course, newest first
+func (r *CourseRepository) GetStatusChanges(courseID uuid.UUID) ([]models.CourseStatusChange, error) {
+	var changes []models.CourseStatusChange
+	err := r.db.Where("course_id = ?", courseID).Order("created_at DESC").Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Delete moves a course and its lessons to the trash and records the audit
+// event. Enrollments, progress and reviews are kept, so restoring the course
+// brings them back.
+func (r *CourseRepository) Delete(id uuid.UUID, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// The lessons share the deletion time so that a restore can tell them
+		// apart from lessons deleted earlier
+		now := time.Now()
+		if err := tx.Model(&models.Lesson{}).Where("course_id = ?", id).UpdateColumn("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Course{}).Where("id = ?", id).UpdateColumn("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
+}
+
+// published restricts a query to the courses listed in the catalog
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.CourseStatusPublished)
+}
+
+// List lists published courses
 func (r *CourseRepository) List(page, pageSize int) ([]models.Course, int64, error) {
 	var courses []models.Course
 	var count int64
 
-	r.db.Model(&models.Course{}).Count(&count)
+	r.db.Model(&models.Course{}).Scopes(published).Count(&count)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("Instructor").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&courses).Error
+	err := r.db.Preload("Instructor").Scopes(published).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&courses).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -71,15 +160,15 @@ func (r *CourseRepository) List(page, pageSize int) ([]models.Course, int64, err
 	return courses, count, nil
 }
 
-// ListByCategory lists courses by category with pagination
+// ListByCategory lists published courses by category with pagination
 func (r *CourseRepository) ListByCategory(category string, page, pageSize int) ([]models.Course, int64, error) {
 	var courses []models.Course
 	var count int64
 
-	r.db.Model(&models.Course{}).Where("category = ?", category).Count(&count)
+	r.db.Model(&models.Course{}).Scopes(published).Where("category = ?", category).Count(&count)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("Instructor").Where("category = ?", category).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&courses).Error
+	err := r.db.Preload("Instructor").Scopes(published).Where("category = ?", category).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&courses).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -102,3 +191,32 @@ func (r *CourseRepository) GetByInstructorID(instructorID uuid.UUID, page, pageS
 
 	return courses, count, nil
 }
+
+// ListManaged lists courses in any status for the course management screens,
+// optionally restricted to one instructor and one status, newest first
+func (r *CourseRepository) ListManaged(instructorID uuid.UUID, status string, page, pageSize int) ([]models.Course, int64, error) {
+	var courses []models.Course
+	var count int64
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		if instructorID != uuid.Nil {
+			db = db.Where("instructor_id = ?", instructorID)
+		}
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	if err := r.db.Model(&models.Course{}).Scopes(filter).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Preload("Instructor").Scopes(filter).Order("updated_at DESC").Offset(offset).Limit(pageSize).Find(&courses).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return courses, count, nil
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CourseReviewRepository struct {
+	db *gorm.DB
+}
+
+// NewCourseReviewRepository creates a new course review repository
+func NewCourseReviewRepository() *CourseReviewRepository {
+	return &CourseReviewRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// Create adds a review and updates the rating of its course
+func (r *CourseReviewRepository) Create(review *models.CourseReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(review).Error; err != nil {
+			return err
+		}
+		return refreshCourseRating(tx, review.CourseID)
+	})
+}
+
+// Update saves a review, updates the rating of its course and records the
+// audit event, if any
+func (r *CourseReviewRepository) Update(review *models.CourseReview, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Save(review).Error; err != nil {
+			return err
+		}
+		if err := refreshCourseRating(tx, review.CourseID); err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
+}
+
+// Delete deletes a review and updates the rating of its course
+func (r *CourseReviewRepository) Delete(review *models.CourseReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.CourseReview{}, review.ID).Error; err != nil {
+			return err
+		}
+		return refreshCourseRating(tx, review.CourseID)
+	})
+}
+
+// GetByID gets a review by ID
+func (r *CourseReviewRepository) GetByID(id uuid.UUID) (*models.CourseReview, error) {
+	var review models.CourseReview
+	err := r.db.Preload("User").Where("id = ?", id).First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ExistsByUserAndCourseID checks if a user has reviewed a course
+func (r *CourseReviewRepository) ExistsByUserAndCourseID(userID, courseID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CourseReview{}).Where("user_id = ? AND course_id = ?", userID, courseID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetByCourseID gets the reviews of a course with pagination, newest first.
+// Hidden reviews are only included when includeHidden is set.
+func (r *CourseReviewRepository) GetByCourseID(courseID uuid.UUID, includeHidden bool, page, pageSize int) ([]models.CourseReview, int64, error) {
+	var reviews []models.CourseReview
+	var count int64
+
+	visible := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("course_id = ?", courseID).Scopes(byLiveUser)
+		if !includeHidden {
+			db = db.Where("hidden_at IS NULL")
+		}
+		return db
+	}
+
+	if err := r.db.Model(&models.CourseReview{}).Scopes(visible).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Scopes(visible).Preload("User").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, count, nil
+}
+
+// RefreshRatingsByUserID recomputes the rating of every course a user reviewed,
+// after the user was deleted or restored, and returns the IDs of those courses
+func (r *CourseReviewRepository) RefreshRatingsByUserID(userID uuid.UUID) ([]uuid.UUID, error) {
+	var courseIDs []uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CourseReview{}).Where("user_id = ?", userID).Pluck("course_id", &courseIDs).Error; err != nil {
+			return err
+		}
+		for _, courseID := range courseIDs {
+			if err := refreshCourseRating(tx, courseID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return courseIDs, err
+}
+
+// byLiveUser skips the reviews of deleted users
+func byLiveUser(db *gorm.DB) *gorm.DB {
+	return db.Where("course_reviews.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)")
+}
+
+// refreshCourseRating recomputes the rating aggregates of a course from its
+// visible reviews by users who have not been deleted
+func refreshCourseRating(tx *gorm.DB, courseID uuid.UUID) error {
+	return tx.Exec(`
+		UPDATE courses SET
+			rating_avg = COALESCE((SELECT ROUND(AVG(rating), 2) FROM course_reviews WHERE course_id = @id AND hidden_at IS NULL
+				AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)), 0),
+			rating_count = (SELECT COUNT(*) FROM course_reviews WHERE course_id = @id AND hidden_at IS NULL
+				AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL))
+		WHERE id = @id`,
+		map[string]interface{}{"id": courseID},
+	).Error
+}
@@ -33,6 +33,16 @@ func (r *EnrollmentRepository) GetByID(id uuid.UUID) (*models.Enrollment, error)
 	return &enrollment, nil
 }
 
+// GetByUserAndCourseID gets an enrollment by user ID and course ID
+func (r *EnrollmentRepository) GetByUserAndCourseID(userID, courseID uuid.UUID) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := r.db.Preload("Course").Preload("Course.Instructor").Where("user_id = ? AND course_id = ?", userID, courseID).First(&enrollment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
 // Delete deletes an enrollment
 func (r *EnrollmentRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Enrollment{}, id).Error
@@ -43,10 +53,10 @@ func (r *EnrollmentRepository) GetByUserID(userID uuid.UUID, page, pageSize int)
 	var enrollments []models.Enrollment
 	var count int64
 
-	r.db.Model(&models.Enrollment{}).Where("user_id = ?", userID).Count(&count)
+	r.db.Model(&models.Enrollment{}).Scopes(liveCourse).Where("user_id = ?", userID).Count(&count)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("Course").Preload("Course.Instructor").Where("user_id = ?", userID).Offset(offset).Limit(pageSize).Find(&enrollments).Error
+	err := r.db.Preload("Course").Preload("Course.Instructor").Scopes(liveCourse).Where("user_id = ?", userID).Order("enrolled_at DESC").Offset(offset).Limit(pageSize).Find(&enrollments).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -59,10 +69,10 @@ func (r *EnrollmentRepository) GetByCourseID(courseID uuid.UUID, page, pageSize
 	var enrollments []models.Enrollment
 	var count int64
 
-	r.db.Model(&models.Enrollment{}).Where("course_id = ?", courseID).Count(&count)
+	r.db.Model(&models.Enrollment{}).Scopes(liveUser).Where("course_id = ?", courseID).Count(&count)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("User").Where("course_id = ?", courseID).Offset(offset).Limit(pageSize).Find(&enrollments).Error
+	err := r.db.Preload("User").Scopes(liveUser).Where("course_id = ?", courseID).Order("enrolled_at ASC").Offset(offset).Limit(pageSize).Find(&enrollments).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -70,6 +80,16 @@ func (r *EnrollmentRepository) GetByCourseID(courseID uuid.UUID, page, pageSize
 	return enrollments, count, nil
 }
 
+// liveCourse skips enrollments in deleted courses
+func liveCourse(db *gorm.DB) *gorm.DB {
+	return db.Where("enrollments.course_id IN (SELECT id FROM courses WHERE deleted_at IS NULL)")
+}
+
+// liveUser skips enrollments of deleted users
+func liveUser(db *gorm.DB) *gorm.DB {
+	return db.Where("enrollments.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)")
+}
+
 // IsEnrolled checks if a user is enrolled in a course
 func (r *EnrollmentRepository) IsEnrolled(userID, courseID uuid.UUID) (bool, error) {
 	var count int64
@@ -18,9 +18,18 @@ func NewLessonRepository() *LessonRepository {
 	}
 }
 
-// Create creates a new lesson
-func (r *LessonRepository) Create(lesson *models.Lesson) error {
-	return r.db.Create(lesson).Error
+// Create creates a new lesson and records its first revision and the audit event
+func (r *LessonRepository) Create(lesson *models.Lesson, revision *models.ContentRevision, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(lesson).Error; err != nil {
+			return err
+		}
+		revision.EntityID = lesson.ID
+		if err := createRevision(tx, revision); err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // GetByID gets a lesson by ID
@@ -33,14 +42,29 @@ func (r *LessonRepository) GetByID(id uuid.UUID) (*models.Lesson, error) {
 	return &lesson, nil
 }
 
-// Update updates a lesson
-func (r *LessonRepository) Update(lesson *models.Lesson) error {
-	return r.db.Save(lesson).Error
+// Update updates a lesson and records the revision of its new content and the
+// audit event
+func (r *LessonRepository) Update(lesson *models.Lesson, revision *models.ContentRevision, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(lesson).Error; err != nil {
+			return err
+		}
+		revision.EntityID = lesson.ID
+		if err := createRevision(tx, revision); err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
-// Delete deletes a lesson
-func (r *LessonRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Lesson{}, id).Error
+// Delete deletes a lesson and records the audit event
+func (r *LessonRepository) Delete(id uuid.UUID, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Lesson{}, id).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // GetByCourseID gets lessons by course ID
@@ -53,6 +77,16 @@ func (r *LessonRepository) GetByCourseID(courseID uuid.UUID) ([]models.Lesson, e
 	return lessons, nil
 }
 
+// GetByCourseAndOrderNumber gets a lesson by course ID and order number
+func (r *LessonRepository) GetByCourseAndOrderNumber(courseID uuid.UUID, orderNumber int) (*models.Lesson, error) {
+	var lesson models.Lesson
+	err := r.db.Where("course_id = ? AND order_number = ?", courseID, orderNumber).First(&lesson).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lesson, nil
+}
+
 // UpdateOrder updates the order of lessons
 func (r *LessonRepository) UpdateOrder(lessons []models.Lesson) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MFARecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewMFARecoveryCodeRepository creates a new MFA recovery code repository
+func NewMFARecoveryCodeRepository() *MFARecoveryCodeRepository {
+	return &MFARecoveryCodeRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// ReplaceForUser deletes the recovery codes of a user and stores new code hashes
+func (r *MFARecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.MFARecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks an unused recovery code as used. It returns false if the code
+// does not exist or was already used.
+func (r *MFARecoveryCodeRepository) Consume(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUnused counts the recovery codes a user can still use
+func (r *MFARecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteByUserID deletes every recovery code of a user
+func (r *MFARecoveryCodeRepository) DeleteByUserID(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProgressRepository struct {
@@ -50,6 +51,19 @@ func (r *ProgressRepository) Update(progress *models.Progress) error {
 	return r.db.Save(progress).Error
 }
 
+// Upsert creates or updates the progress for a user and lesson.
+// A lesson that was already completed stays completed.
+func (r *ProgressRepository) Upsert(progress *models.Progress) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "lesson_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"position_seconds": gorm.Expr("excluded.position_seconds"),
+			"completed":        gorm.Expr("progress.completed OR excluded.completed"),
+			"last_watched_at":  gorm.Expr("excluded.last_watched_at"),
+		}),
+	}).Create(progress).Error
+}
+
 // UpdatePosition updates the position of a progress
 func (r *ProgressRepository) UpdatePosition(userID, lessonID uuid.UUID, positionSeconds int) error {
 	return r.db.Model(&models.Progress{}).
@@ -83,7 +97,7 @@ func (r *ProgressRepository) GetByUserID(userID uuid.UUID) ([]models.Progress, e
 // GetByCourseAndUserID gets progress by course ID and user ID
 func (r *ProgressRepository) GetByCourseAndUserID(courseID, userID uuid.UUID) ([]models.Progress, error) {
 	var progress []models.Progress
-	err := r.db.Joins("JOIN lessons ON lessons.id = progress.lesson_id").
+	err := r.db.Joins("JOIN lessons ON lessons.id = progress.lesson_id AND lessons.deleted_at IS NULL").
 		Where("lessons.course_id = ? AND progress.user_id = ?", courseID, userID).
 		Preload("Lesson").
 		Find(&progress).Error
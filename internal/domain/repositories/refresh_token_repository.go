@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
 	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/google/uuid"
@@ -33,10 +35,10 @@ func (r *RefreshTokenRepository) GetByID(id uuid.UUID) (*models.RefreshToken, er
 	return &token, nil
 }
 
-// GetByToken gets a refresh token by token
-func (r *RefreshTokenRepository) GetByToken(token string) (*models.RefreshToken, error) {
+// GetByTokenHash gets a refresh token by the hash of its value
+func (r *RefreshTokenRepository) GetByTokenHash(tokenHash string) (*models.RefreshToken, error) {
 	var refreshToken models.RefreshToken
-	err := r.db.Where("token = ?", token).First(&refreshToken).Error
+	err := r.db.Where("token_hash = ?", tokenHash).First(&refreshToken).Error
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +55,19 @@ func (r *RefreshTokenRepository) GetByUserID(userID uuid.UUID) ([]models.Refresh
 	return tokens, nil
 }
 
+// GetActiveByUserID gets the current token of every live session of a user,
+// most recently used first
+func (r *RefreshTokenRepository) GetActiveByUserID(userID uuid.UUID) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.db.Where("user_id = ? AND rotated_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
 // Delete deletes a refresh token
 func (r *RefreshTokenRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.RefreshToken{}, id).Error
@@ -62,3 +77,43 @@ func (r *RefreshTokenRepository) Delete(id uuid.UUID) error {
 func (r *RefreshTokenRepository) DeleteByUserID(userID uuid.UUID) error {
 	return r.db.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error
 }
+
+// DeleteByUserIDExceptFamily deletes refresh tokens by user ID, keeping the given token family
+func (r *RefreshTokenRepository) DeleteByUserIDExceptFamily(userID, familyID uuid.UUID) error {
+	return r.db.Where("user_id = ? AND family_id <> ?", userID, familyID).Delete(&models.RefreshToken{}).Error
+}
+
+// DeleteByUserIDAndFamilyID deletes a token family of a user. It returns false
+// if the user has no such family.
+func (r *RefreshTokenRepository) DeleteByUserIDAndFamilyID(userID, familyID uuid.UUID) (bool, error) {
+	result := r.db.Where("user_id = ? AND family_id = ?", userID, familyID).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteByFamilyID deletes every refresh token of a token family
+func (r *RefreshTokenRepository) DeleteByFamilyID(familyID uuid.UUID) error {
+	return r.db.Where("family_id = ?", familyID).Delete(&models.RefreshToken{}).Error
+}
+
+// MarkRotated marks a refresh token as used. It returns false if the token
+// had already been rotated, which means it is being replayed.
+func (r *RefreshTokenRepository) MarkRotated(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkFamilyMFAVerified records that the session of a token family passed a
+// second factor, so that tokens rotated from it inherit the flag
+func (r *RefreshTokenRepository) MarkFamilyMFAVerified(familyID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ?", familyID).
+		Update("mfa_verified", true).Error
+}
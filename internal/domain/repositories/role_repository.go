@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// GetByName gets a role by name with its permissions
+func (r *RoleRepository) GetByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// List lists all roles with their permissions
+func (r *RoleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name ASC").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// GetPermissionNames gets the permission names granted to a role
+func (r *RoleRepository) GetPermissionNames(roleName string) ([]string, error) {
+	var names []string
+	err := r.db.Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", roleName).
+		Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// AssignUserRole changes a user's role and records the change and the audit event
+func (r *RoleRepository) AssignUserRole(change *models.RoleChange, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", change.UserID).Update("role", change.NewRole).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
+}
+
+// GetRoleChangesByUserID gets the role changes of a user, newest first
+func (r *RoleRepository) GetRoleChangesByUserID(userID uuid.UUID) ([]models.RoleChange, error) {
+	var changes []models.RoleChange
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrashRepository reads, restores and purges soft-deleted courses, lessons,
+// users and categories
+type TrashRepository struct {
+	db *gorm.DB
+}
+
+// NewTrashRepository creates a new trash repository
+func NewTrashRepository() *TrashRepository {
+	return &TrashRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// PurgeResult counts the records removed by a purge
+type PurgeResult struct {
+	Lessons    int64
+	Courses    int64
+	Categories int64
+	Users      int64
+}
+
+// deleted selects soft-deleted rows only
+func deleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// List lists the deleted rows of a model, most recently deleted first.
+// dest must be a pointer to a slice of the model.
+func (r *TrashRepository) List(model, dest interface{}, page, pageSize int) (int64, error) {
+	var count int64
+	r.db.Model(model).Scopes(deleted).Count(&count)
+
+	offset := (page - 1) * pageSize
+	err := r.db.Scopes(deleted).Order("deleted_at DESC").Offset(offset).Limit(pageSize).Find(dest).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetByID gets a deleted row by ID. dest must be a pointer to the model.
+func (r *TrashRepository) GetByID(dest interface{}, id uuid.UUID) error {
+	return r.db.Scopes(deleted).Where("id = ?", id).First(dest).Error
+}
+
+// Restore takes a deleted row out of the trash and records the audit event
+func (r *TrashRepository) Restore(model interface{}, id uuid.UUID, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(model).Unscoped().Where("id = ?", id).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
+}
+
+// RestoreCourse takes a course out of the trash together with the lessons
+// deleted with it, and records the audit event. Lessons deleted before the
+// course stay in the trash.
+func (r *TrashRepository) RestoreCourse(course *models.Course, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Lesson{}).Unscoped().
+			Where("course_id = ? AND deleted_at = ?", course.ID, course.DeletedAt.Time).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Course{}).Unscoped().Where("id = ?", course.ID).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
+}
+
+// Purge permanently removes the rows deleted before the cutoff, and the content
+// revisions of the removed courses and lessons. The foreign keys remove their
+// enrollments, progress and reviews. Deleted users who still instruct a course
+// are kept.
+func (r *TrashRepository) Purge(cutoff time.Time) (*PurgeResult, error) {
+	result := &PurgeResult{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		}
+
+		// Revisions have no foreign key to their course or lesson and the
+		// database rejects deleting them, except in a transaction marked as a
+		// purge. The revisions of the expired courses and lessons are deleted
+		// here, before the courses and lessons themselves.
+		if err := tx.Exec("SET LOCAL app.purging_content_revisions = 'on'").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			DELETE FROM content_revisions
+			WHERE (entity_type = ? AND entity_id IN (SELECT id FROM courses WHERE deleted_at IS NOT NULL AND deleted_at < ?))
+				OR (entity_type = ? AND entity_id IN (SELECT id FROM lessons WHERE deleted_at IS NOT NULL AND deleted_at < ?))`,
+			models.RevisionEntityCourse, cutoff, models.RevisionEntityLesson, cutoff,
+		).Error; err != nil {
+			return err
+		}
+
+		res := tx.Scopes(expired).Delete(&models.Lesson{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Lessons = res.RowsAffected
+
+		res = tx.Scopes(expired).Delete(&models.Course{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Courses = res.RowsAffected
+
+		res = tx.Scopes(expired).Delete(&models.Category{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Categories = res.RowsAffected
+
+		res = tx.Scopes(expired).
+			Where("NOT EXISTS (SELECT 1 FROM courses WHERE courses.instructor_id = users.id)").
+			Delete(&models.User{})
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Users = res.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
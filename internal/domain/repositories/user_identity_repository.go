@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository creates a new user identity repository
+func NewUserIdentityRepository() *UserIdentityRepository {
+	return &UserIdentityRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// Create links a provider account to a user
+func (r *UserIdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Omit("User").Create(identity).Error
+}
+
+// CreateUserWithIdentity creates a user together with the provider account it signed up with
+func (r *UserIdentityRepository) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Wallets").Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Omit("User").Create(identity).Error
+	})
+}
+
+// GetByID gets an identity by ID
+func (r *UserIdentityRepository) GetByID(id uuid.UUID) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("id = ?", id).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// GetByProviderSubject gets the identity of a provider account, with its user
+func (r *UserIdentityRepository) GetByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Preload("User").Preload("User.Wallets", orderWallets).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// GetByUserID gets the identities linked to a user
+func (r *UserIdentityRepository) GetByUserID(userID uuid.UUID) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// CountByUserID counts the identities linked to a user
+func (r *UserIdentityRepository) CountByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// UpdateLastLogin records a login with an identity
+func (r *UserIdentityRepository) UpdateLastLogin(id uuid.UUID, email string) error {
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": time.Now(),
+	}).Error
+}
+
+// Delete unlinks an identity
+func (r *UserIdentityRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.UserIdentity{}, id).Error
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -26,7 +27,7 @@ func (r *UserRepository) Create(user *models.User) error {
 // GetByID gets a user by ID
 func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.db.Preload("Wallets", orderWallets).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -36,21 +37,31 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 // GetByEmail gets a user by email
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Wallets", orderWallets).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// Update updates a user
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+// Update updates a user and records the audit event, if any
+func (r *UserRepository) Update(user *models.User, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(user).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
-// Delete deletes a user
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.User{}, id).Error
+// Delete deletes a user and records the audit event
+func (r *UserRepository) Delete(id uuid.UUID, event *models.AuditEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.User{}, id).Error; err != nil {
+			return err
+		}
+		return createAuditEvent(tx, event)
+	})
 }
 
 // List lists all users
@@ -61,10 +72,15 @@ func (r *UserRepository) List(page, pageSize int) ([]models.User, int64, error)
 	r.db.Model(&models.User{}).Count(&count)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Offset(offset).Limit(pageSize).Find(&users).Error
+	err := r.db.Preload("Wallets", orderWallets).Offset(offset).Limit(pageSize).Find(&users).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return users, count, nil
 }
+
+// orderWallets lists the primary wallet first, then the oldest
+func orderWallets(db *gorm.DB) *gorm.DB {
+	return db.Order("is_primary DESC, created_at ASC")
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserWalletRepository struct {
+	db *gorm.DB
+}
+
+// NewUserWalletRepository creates a new user wallet repository
+func NewUserWalletRepository() *UserWalletRepository {
+	return &UserWalletRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// Create links a wallet to a user. A primary wallet replaces the current one.
+func (r *UserWalletRepository) Create(wallet *models.UserWallet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if wallet.IsPrimary {
+			if err := tx.Model(&models.UserWallet{}).Where("user_id = ?", wallet.UserID).Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(wallet).Error
+	})
+}
+
+// CreateUserWithWallet creates a wallet-only user together with its wallet
+func (r *UserWalletRepository) CreateUserWithWallet(user *models.User, wallet *models.UserWallet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Wallets").Create(user).Error; err != nil {
+			return err
+		}
+		wallet.UserID = user.ID
+		return tx.Create(wallet).Error
+	})
+}
+
+// GetByID gets a wallet by ID
+func (r *UserWalletRepository) GetByID(id uuid.UUID) (*models.UserWallet, error) {
+	var wallet models.UserWallet
+	err := r.db.Where("id = ?", id).First(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// GetByAddress gets a wallet by its lower-case address, with its user and the user's wallets
+func (r *UserWalletRepository) GetByAddress(address string) (*models.UserWallet, error) {
+	var wallet models.UserWallet
+	err := r.db.Preload("User").Preload("User.Wallets", orderWallets).Where("address = ?", address).First(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// GetByUserID gets the wallets of a user, primary first
+func (r *UserWalletRepository) GetByUserID(userID uuid.UUID) ([]models.UserWallet, error) {
+	var wallets []models.UserWallet
+	err := orderWallets(r.db).Where("user_id = ?", userID).Find(&wallets).Error
+	if err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// Delete unlinks a wallet. When the primary wallet is removed, the oldest
+// remaining wallet becomes primary.
+func (r *UserWalletRepository) Delete(wallet *models.UserWallet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.UserWallet{}, wallet.ID).Error; err != nil {
+			return err
+		}
+		if !wallet.IsPrimary {
+			return nil
+		}
+
+		var next models.UserWallet
+		err := tx.Where("user_id = ?", wallet.UserID).Order("created_at ASC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/postgres"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewWebAuthnCredentialRepository creates a new WebAuthn credential repository
+func NewWebAuthnCredentialRepository() *WebAuthnCredentialRepository {
+	return &WebAuthnCredentialRepository{
+		db: postgres.GetDB(),
+	}
+}
+
+// Create registers a passkey
+func (r *WebAuthnCredentialRepository) Create(credential *models.WebAuthnCredential) error {
+	return r.db.Create(credential).Error
+}
+
+// GetByID gets a passkey by ID
+func (r *WebAuthnCredentialRepository) GetByID(id uuid.UUID) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	err := r.db.Where("id = ?", id).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// GetByCredentialID gets a passkey by the credential ID chosen by its authenticator
+func (r *WebAuthnCredentialRepository) GetByCredentialID(credentialID []byte) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	err := r.db.Where("credential_id = ?", credentialID).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// GetByUserID gets the passkeys of a user
+func (r *WebAuthnCredentialRepository) GetByUserID(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	return credentials, err
+}
+
+// CountByUserID counts the passkeys of a user
+func (r *WebAuthnCredentialRepository) CountByUserID(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// UpdateAfterLogin records a login with a passkey and the state reported by its authenticator
+func (r *WebAuthnCredentialRepository) UpdateAfterLogin(id uuid.UUID, signCount uint32, backupState bool) error {
+	return r.db.Model(&models.WebAuthnCredential{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"sign_count":   signCount,
+		"backup_state": backupState,
+		"last_used_at": time.Now(),
+	}).Error
+}
+
+// Rename renames a passkey
+func (r *WebAuthnCredentialRepository) Rename(id uuid.UUID, name string) error {
+	return r.db.Model(&models.WebAuthnCredential{}).Where("id = ?", id).Update("name", name).Error
+}
+
+// Delete removes a passkey
+func (r *WebAuthnCredentialRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.WebAuthnCredential{}, id).Error
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// auditExportBatchSize is the number of audit events read at a time for an export
+const auditExportBatchSize = 500
+
+// AuditService writes and searches the audit log of privileged actions
+type AuditService struct {
+	auditRepo *repositories.AuditEventRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService() *AuditService {
+	return &AuditService{
+		auditRepo: repositories.NewAuditEventRepository(),
+	}
+}
+
+// AuditRecord describes one privileged request. The audit middleware fills in
+// the actor and the request. Services turn each change they make into an audit
+// event with Change, and write the event in the transaction that makes the
+// change. The middleware writes a request without changes, or one that failed
+// or was denied, once it has been handled, with its route as the action.
+// Change returns nil on a nil record, so services can be used outside of
+// audited routes.
+type AuditRecord struct {
+	ActorID    *uuid.UUID
+	ActorRole  string
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	Method     string
+	Path       string
+	IPAddress  string
+	UserAgent  string
+	RequestID  string
+
+	changed bool
+}
+
+// Change describes a change a service is about to make as an audit event, to
+// be written in the same transaction as the change. before is nil for created
+// records and after is nil for deleted ones. Both are stored as JSON right
+// away, so they may be changed afterwards.
+func (r *AuditRecord) Change(action, targetType string, targetID uuid.UUID, before, after interface{}) *models.AuditEvent {
+	if r == nil {
+		return nil
+	}
+	r.changed = true
+
+	event := r.event(action, targetType, &targetID)
+	event.Before = auditJSON(before)
+	event.After = auditJSON(after)
+	return event
+}
+
+// event builds an audit event of the request
+func (r *AuditRecord) event(action, targetType string, targetID *uuid.UUID) *models.AuditEvent {
+	return &models.AuditEvent{
+		ActorID:    r.ActorID,
+		ActorRole:  r.ActorRole,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Method:     r.Method,
+		Path:       r.Path,
+		IPAddress:  r.IPAddress,
+		UserAgent:  r.UserAgent,
+		RequestID:  r.RequestID,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// auditJSON encodes the state of a record, or returns nil for no record
+func auditJSON(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// AuditFilter narrows down the audit events to list or export
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditEventResponse represents an audit event
+type AuditEventResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorRole  string          `json:"actor_role,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID      `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Status     *int            `json:"status,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// newAuditEventResponse maps an audit event model to an audit event response
+func newAuditEventResponse(event *models.AuditEvent) AuditEventResponse {
+	return AuditEventResponse{
+		ID:         event.ID,
+		ActorID:    event.ActorID,
+		ActorRole:  event.ActorRole,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     event.Before,
+		After:      event.After,
+		Method:     event.Method,
+		Path:       event.Path,
+		Status:     event.Status,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		RequestID:  event.RequestID,
+		CreatedAt:  event.CreatedAt,
+	}
+}
+
+// Write records a handled request with its response status, unless it
+// succeeded and its changes were recorded together with them
+func (s *AuditService) Write(record *AuditRecord, status int) error {
+	if record.changed && status < http.StatusBadRequest {
+		return nil
+	}
+
+	event := record.event(record.Action, record.TargetType, record.TargetID)
+	event.Status = &status
+	return s.auditRepo.Create(event)
+}
+
+// List lists the audit events matching a filter, newest first
+func (s *AuditService) List(filter AuditFilter, page, pageSize int) ([]AuditEventResponse, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	events, count, err := s.auditRepo.List(repositories.AuditEventFilter(filter), page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := []AuditEventResponse{}
+	for i := range events {
+		responses = append(responses, newAuditEventResponse(&events[i]))
+	}
+	return responses, count, nil
+}
+
+// ExportNDJSON writes the audit events matching a filter as newline-delimited
+// JSON, one event per line, oldest first
+func (s *AuditService) ExportNDJSON(filter AuditFilter, w io.Writer) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	return s.auditRepo.Each(repositories.AuditEventFilter(filter), auditExportBatchSize, func(events []models.AuditEvent) error {
+		for i := range events {
+			if err := encoder.Encode(newAuditEventResponse(&events[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Validate checks that the filter's time range is not empty
+func (f AuditFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
@@ -2,21 +2,43 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/0xBoji/web3-edu-core/config"
 	"github.com/0xBoji/web3-edu-core/internal/database/redis"
 	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/mailer"
 	"github.com/0xBoji/web3-edu-core/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	// resetTokenExpiration is how long a password reset link stays valid
+	resetTokenExpiration = 1 * time.Hour
+	// verificationTokenExpiration is how long an email verification link stays valid
+	verificationTokenExpiration = 24 * time.Hour
+	// magicLinkExpiration is how long a magic login link stays valid
+	magicLinkExpiration = 15 * time.Minute
+	// magicLinkPurpose is the nonce purpose of magic login links
+	magicLinkPurpose = "magic_link"
+)
+
 type AuthService struct {
 	userRepo         *repositories.UserRepository
 	refreshTokenRepo *repositories.RefreshTokenRepository
+	tokenService     *TokenService
+	lockoutService   *LockoutService
 	cache            *redis.Cache
+	mailer           mailer.Mailer
 }
 
 // NewAuthService creates a new auth service
@@ -24,55 +46,137 @@ func NewAuthService() *AuthService {
 	return &AuthService{
 		userRepo:         repositories.NewUserRepository(),
 		refreshTokenRepo: repositories.NewRefreshTokenRepository(),
+		tokenService:     NewTokenService(),
+		lockoutService:   NewLockoutService(),
 		cache:            redis.NewCache(),
+		mailer:           mailer.NewMailer(),
 	}
 }
 
 // RegisterRequest represents the register request
 type RegisterRequest struct {
 	Email          string `json:"email" binding:"required,email"`
-	Password       string `json:"password" binding:"required,min=6"`
+	Password       string `json:"password" binding:"required"`
 	FullName       string `json:"full_name" binding:"required"`
-	Role           string `json:"role"`
 	ProfilePicture string `json:"profile_picture"`
+	Language       string `json:"language"`
+	DeviceName     string `json:"device_name"`
 }
 
 // LoginRequest represents the login request
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name"`
+	Language   string `json:"language"`
+}
+
+// ClientInfo describes the client a session is created or refreshed from
+type ClientInfo struct {
+	UserAgent  string
+	IPAddress  string
+	DeviceName string
 }
 
 // ForgotPasswordRequest represents the forgot password request
 type ForgotPasswordRequest struct {
-	Email string `json:"email" binding:"required,email"`
+	Email    string `json:"email" binding:"required,email"`
+	Language string `json:"language"`
+}
+
+// MagicLinkRequest represents the magic link request
+type MagicLinkRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Language string `json:"language"`
+}
+
+// MagicLinkResponse carries the nonce that binds a magic link to the browser
+// that requested it. It has to be sent back together with the link token.
+type MagicLinkResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MagicLinkVerifyRequest represents the magic link verify request
+type MagicLinkVerifyRequest struct {
+	Token      string `json:"token" binding:"required"`
+	Nonce      string `json:"nonce" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// magicLink is what is stored in Redis for a magic login link
+type magicLink struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	NonceHash string    `json:"nonce_hash"`
+}
+
+// VerifyEmailRequest represents the verify email request
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest represents the resend verification email request
+type ResendVerificationRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Language string `json:"language"`
 }
 
 // ResetPasswordRequest represents the reset password request
 type ResetPasswordRequest struct {
 	Token    string `json:"token" binding:"required"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
-// TokenResponse represents the token response
+// TokenResponse represents the token response. Tokens are left empty when the
+// user has to verify their email address before logging in. When the user has
+// two-factor authentication enabled, only MFAToken is set and has to be
+// exchanged for tokens together with a code.
 type TokenResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	ExpiresAt    time.Time    `json:"expires_at"`
-	User         UserResponse `json:"user"`
+	AccessToken          string       `json:"access_token,omitempty"`
+	RefreshToken         string       `json:"refresh_token,omitempty"`
+	ExpiresAt            time.Time    `json:"expires_at,omitzero"`
+	VerificationRequired bool         `json:"verification_required,omitempty"`
+	MFARequired          bool         `json:"mfa_required,omitempty"`
+	MFAToken             string       `json:"mfa_token,omitempty"`
+	User                 UserResponse `json:"user,omitzero"`
 }
 
 // UserResponse represents the user response
 type UserResponse struct {
-	ID             uuid.UUID `json:"id"`
-	Email          string    `json:"email"`
-	FullName       string    `json:"full_name"`
-	Role           string    `json:"role"`
-	ProfilePicture string    `json:"profile_picture,omitempty"`
+	ID              uuid.UUID        `json:"id"`
+	Email           string           `json:"email,omitempty"`
+	WalletAddress   string           `json:"wallet_address,omitempty"`
+	Wallets         []WalletResponse `json:"wallets,omitempty"`
+	FullName        string           `json:"full_name"`
+	Role            string           `json:"role"`
+	ProfilePicture  string           `json:"profile_picture,omitempty"`
+	EmailVerifiedAt *time.Time       `json:"email_verified_at,omitempty"`
+	MFAEnabled      bool             `json:"mfa_enabled"`
+}
+
+// newUserResponse maps a user model to a user response
+func newUserResponse(user *models.User) UserResponse {
+	response := UserResponse{
+		ID:              user.ID,
+		Email:           user.EmailAddress(),
+		FullName:        user.FullName,
+		Role:            user.Role,
+		ProfilePicture:  user.ProfilePicture,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MFAEnabled:      user.TOTPEnabledAt != nil,
+	}
+	if wallet := user.PrimaryWallet(); wallet != nil {
+		response.WalletAddress = utils.ChecksumAddress(wallet.Address)
+	}
+	for i := range user.Wallets {
+		response.Wallets = append(response.Wallets, newWalletResponse(&user.Wallets[i]))
+	}
+	return response
 }
 
 // Register registers a new user
-func (s *AuthService) Register(req RegisterRequest) (*TokenResponse, error) {
+func (s *AuthService) Register(req RegisterRequest, client ClientInfo) (*TokenResponse, error) {
 	// Check if user already exists
 	_, err := s.userRepo.GetByEmail(req.Email)
 	if err == nil {
@@ -81,23 +185,23 @@ func (s *AuthService) Register(req RegisterRequest) (*TokenResponse, error) {
 		return nil, err
 	}
 
+	// Enforce password policy
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set default role if not provided
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
-	// Create user
+	// New accounts are always learners; other roles are granted by an admin
 	user := &models.User{
-		Email:          req.Email,
+		Email:          &req.Email,
 		PasswordHash:   hashedPassword,
 		FullName:       req.FullName,
-		Role:           req.Role,
+		Role:           DefaultRole,
 		ProfilePicture: req.ProfilePicture,
 	}
 
@@ -105,38 +209,88 @@ func (s *AuthService) Register(req RegisterRequest) (*TokenResponse, error) {
 		return nil, err
 	}
 
+	// Send verification email
+	if err := s.sendVerificationEmail(user, req.Language); err != nil {
+		return nil, err
+	}
+
+	// Unverified users may not log in until they confirm their email address
+	if loginRequiresVerifiedEmail() {
+		return &TokenResponse{
+			VerificationRequired: true,
+			User:                 newUserResponse(user),
+		}, nil
+	}
+
 	// Generate tokens
-	return s.generateTokens(user)
+	return s.generateTokens(user, nil, client, false)
 }
 
-// Login logs in a user
-func (s *AuthService) Login(req LoginRequest) (*TokenResponse, error) {
+// Login logs in a user. Failed attempts are throttled per email address.
+func (s *AuthService) Login(req LoginRequest, client ClientInfo) (*TokenResponse, error) {
+	if err := s.lockoutService.Check(req.Email); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Spend the same time as for a wrong password
+			utils.CheckPasswordHash(req.Password, dummyPasswordHash())
+			s.loginFailed(req.Email, nil, req.Language)
 			return nil, errors.New("invalid email or password")
 		}
 		return nil, err
 	}
 
-	// Check password
-	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+	// Check password. Wallet-only accounts have none.
+	passwordHash := user.PasswordHash
+	if passwordHash == "" {
+		passwordHash = dummyPasswordHash()
+	}
+	if !utils.CheckPasswordHash(req.Password, passwordHash) || user.PasswordHash == "" {
+		s.loginFailed(req.Email, user, req.Language)
 		return nil, errors.New("invalid email or password")
 	}
 
-	// Generate tokens
-	return s.generateTokens(user)
+	if err := s.lockoutService.Reset(req.Email); err != nil {
+		return nil, err
+	}
+
+	// Upgrade hashes made with an older algorithm or weaker parameters
+	if utils.NeedsRehash(user.PasswordHash) {
+		if hashedPassword, err := utils.HashPassword(req.Password); err == nil {
+			user.PasswordHash = hashedPassword
+			if err := s.userRepo.Update(user, nil); err != nil {
+				log.Printf("Failed to upgrade password hash of user %s: %v", user.ID, err)
+			}
+		}
+	}
+
+	// Check email verification
+	if user.EmailVerifiedAt == nil && loginRequiresVerifiedEmail() {
+		return nil, errors.New("email address is not verified")
+	}
+
+	return s.beginSession(user, client)
 }
 
-// RefreshToken refreshes the access token
-func (s *AuthService) RefreshToken(refreshToken string) (*TokenResponse, error) {
+// RefreshToken rotates a refresh token and issues a new access token. Replaying
+// a token that was already rotated revokes every token of its family.
+func (s *AuthService) RefreshToken(refreshToken string, client ClientInfo) (*TokenResponse, error) {
 	// Get refresh token
-	token, err := s.refreshTokenRepo.GetByToken(refreshToken)
+	token, err := s.refreshTokenRepo.GetByTokenHash(utils.HashToken(refreshToken))
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
+	// A rotated token must never be presented again
+	if token.RotatedAt != nil {
+		s.revokeFamily(token)
+		return nil, errors.New("invalid refresh token")
+	}
+
 	// Check if token is expired
 	if token.ExpiresAt.Before(time.Now()) {
 		return nil, errors.New("refresh token expired")
@@ -148,29 +302,53 @@ func (s *AuthService) RefreshToken(refreshToken string) (*TokenResponse, error)
 		return nil, err
 	}
 
-	// Delete old refresh token
-	if err := s.refreshTokenRepo.Delete(token.ID); err != nil {
+	// Mark the old token as rotated. Losing the race to a concurrent request
+	// with the same token counts as a replay.
+	rotated, err := s.refreshTokenRepo.MarkRotated(token.ID)
+	if err != nil {
 		return nil, err
 	}
+	if !rotated {
+		s.revokeFamily(token)
+		return nil, errors.New("invalid refresh token")
+	}
 
 	// Generate new tokens
-	return s.generateTokens(user)
+	return s.generateTokens(user, token, client, token.MFAVerified)
 }
 
-// Logout logs out a user
-func (s *AuthService) Logout(refreshToken string) error {
+// Logout logs out a user. accessClaims are the claims of the caller's access
+// token, if one was sent, which is revoked as well.
+func (s *AuthService) Logout(refreshToken string, accessClaims *utils.Claims) error {
+	if accessClaims != nil {
+		if err := s.tokenService.RevokeAccessToken(accessClaims); err != nil {
+			return err
+		}
+	}
+
 	// Get refresh token
-	token, err := s.refreshTokenRepo.GetByToken(refreshToken)
+	token, err := s.refreshTokenRepo.GetByTokenHash(utils.HashToken(refreshToken))
 	if err != nil {
 		return nil // Ignore error if token not found
 	}
 
-	// Delete refresh token
-	return s.refreshTokenRepo.Delete(token.ID)
+	// Revoke the access tokens of the session and delete the whole token family
+	if err := s.tokenService.RevokeSession(token.FamilyID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.DeleteByFamilyID(token.FamilyID)
 }
 
 // ForgotPassword initiates the forgot password process
-func (s *AuthService) ForgotPassword(req ForgotPasswordRequest) error {
+func (s *AuthService) ForgotPassword(req ForgotPasswordRequest, client ClientInfo) error {
+	allowed, err := s.lockoutService.AllowPasswordReset(req.Email, client.IPAddress)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil // Too many emails for this address
+	}
+
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
@@ -188,17 +366,201 @@ func (s *AuthService) ForgotPassword(req ForgotPasswordRequest) error {
 	key := "reset_token:" + token
 	userData := map[string]string{
 		"user_id": user.ID.String(),
-		"email":   user.Email,
+		"email":   user.EmailAddress(),
 	}
-	if err := s.cache.SetJSON(ctx, key, userData, 1*time.Hour); err != nil {
+	if err := s.cache.SetJSON(ctx, key, userData, resetTokenExpiration); err != nil {
 		return err
 	}
 
-	// In a real application, you would send an email with the reset link
-	// For now, we'll just return success
+	// Send the reset link
+	s.sendMail(user.EmailAddress(), "password_reset", req.Language, map[string]string{
+		"name":    user.FullName,
+		"link":    frontendLink("/reset-password", token),
+		"minutes": strconv.Itoa(int(resetTokenExpiration.Minutes())),
+	})
+
 	return nil
 }
 
+// RequestMagicLink emails a single-use login link. The returned nonce binds the
+// link to the requesting browser. A nonce is returned for unknown or throttled
+// addresses as well, so the response does not reveal whether an account exists.
+func (s *AuthService) RequestMagicLink(req MagicLinkRequest, client ClientInfo) (*MagicLinkResponse, error) {
+	nonce, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	response := &MagicLinkResponse{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(magicLinkExpiration),
+	}
+
+	allowed, err := s.lockoutService.AllowMagicLink(req.Email, client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return response, nil // Too many emails for this address
+	}
+
+	// Get user by email
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response, nil // Don't reveal that the email doesn't exist
+		}
+		return nil, err
+	}
+
+	token, err := utils.GenerateSignedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only hashes of the token and the nonce are stored
+	ctx := context.Background()
+	link := magicLink{
+		UserID:    user.ID,
+		Email:     user.EmailAddress(),
+		NonceHash: utils.HashToken(nonce),
+	}
+	if err := s.cache.SetNonce(ctx, magicLinkPurpose, utils.HashToken(token), link, magicLinkExpiration); err != nil {
+		return nil, err
+	}
+
+	s.sendMail(user.EmailAddress(), "magic_link", req.Language, map[string]string{
+		"name":    user.FullName,
+		"link":    frontendLink("/magic-link", token),
+		"minutes": strconv.Itoa(int(magicLinkExpiration.Minutes())),
+	})
+
+	return response, nil
+}
+
+// VerifyMagicLink exchanges a magic link token and the nonce of the browser that
+// requested it for tokens. The link proves access to the mailbox, so it also
+// verifies the email address and lifts a lockout.
+func (s *AuthService) VerifyMagicLink(req MagicLinkVerifyRequest, client ClientInfo) (*TokenResponse, error) {
+	if !utils.VerifySignedToken(req.Token) {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	// A link opened in another browser is rejected without using it up
+	ctx := context.Background()
+	tokenHash := utils.HashToken(req.Token)
+	var link magicLink
+	if err := s.cache.PeekNonce(ctx, magicLinkPurpose, tokenHash, &link); err != nil {
+		return nil, errors.New("invalid or expired link")
+	}
+	if subtle.ConstantTimeCompare([]byte(link.NonceHash), []byte(utils.HashToken(req.Nonce))) != 1 {
+		return nil, errors.New("invalid or expired link")
+	}
+	if err := s.cache.ConsumeNonce(ctx, magicLinkPurpose, tokenHash, &link); err != nil {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	user, err := s.userRepo.GetByID(link.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired link")
+		}
+		return nil, err
+	}
+
+	// The link is only valid for the address it was sent to
+	if user.EmailAddress() != link.Email {
+		return nil, errors.New("invalid or expired link")
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.Update(user, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.lockoutService.Reset(link.Email); err != nil {
+		return nil, err
+	}
+
+	return s.beginSession(user, client)
+}
+
+// VerifyEmail marks a user's email address as verified
+func (s *AuthService) VerifyEmail(req VerifyEmailRequest) (*UserResponse, error) {
+	if !utils.VerifySignedToken(req.Token) {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	// Get token from Redis
+	ctx := context.Background()
+	key := "verify_token:" + req.Token
+	var userData map[string]string
+	if err := s.cache.GetJSON(ctx, key, &userData); err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	// Parse user ID
+	userID, err := uuid.Parse(userData["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	// Get user
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token is only valid for the address it was sent to
+	if user.EmailAddress() != userData["email"] {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.Update(user, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Delete token from Redis
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+
+	response := newUserResponse(user)
+	return &response, nil
+}
+
+// ResendVerification sends a new verification email to an unverified user.
+// Requests are throttled per IP and per email address.
+func (s *AuthService) ResendVerification(req ResendVerificationRequest, client ClientInfo) error {
+	allowed, err := s.lockoutService.AllowVerificationEmail(req.Email, client.IPAddress)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil // Too many emails for this address
+	}
+
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // Don't reveal that the email doesn't exist
+		}
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.sendVerificationEmail(user, req.Language)
+}
+
 // ResetPassword resets a user's password
 func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 	// Get token from Redis
@@ -221,6 +583,11 @@ func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 		return err
 	}
 
+	// Enforce password policy
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -229,7 +596,7 @@ func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 
 	// Update user password
 	user.PasswordHash = hashedPassword
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(user, nil); err != nil {
 		return err
 	}
 
@@ -238,18 +605,152 @@ func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 		return err
 	}
 
+	// Revoke access tokens issued before the reset
+	if err := s.tokenService.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+
+	// Proving access to the mailbox lifts a lockout
+	if err := s.lockoutService.Reset(user.EmailAddress()); err != nil {
+		return err
+	}
+
 	// Delete all refresh tokens for this user
 	return s.refreshTokenRepo.DeleteByUserID(user.ID)
 }
 
-// generateTokens generates access and refresh tokens
-func (s *AuthService) generateTokens(user *models.User) (*TokenResponse, error) {
-	// Generate access token
-	accessToken, err := utils.GenerateToken(user.ID, user.Email, user.Role)
+// loginFailed records a failed login and tells the user when their account got locked
+func (s *AuthService) loginFailed(email string, user *models.User, language string) {
+	if email == "" {
+		return
+	}
+
+	locked, err := s.lockoutService.RecordFailure(email)
+	if err != nil {
+		log.Printf("Failed to record failed login: %v", err)
+		return
+	}
+	if !locked || user == nil {
+		return
+	}
+
+	log.Printf("SECURITY: account %s locked after repeated failed logins", user.ID)
+	s.sendMail(email, "account_locked", language, map[string]string{
+		"name":    user.FullName,
+		"link":    strings.TrimRight(config.AppSetting.FrontendURL, "/") + "/forgot-password",
+		"minutes": strconv.Itoa(int(lockoutDuration().Minutes())),
+	})
+}
+
+// dummyPasswordHash is compared against when there is no password to check, so
+// that unknown accounts take as long to reject as wrong passwords
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := utils.HashPassword(uuid.New().String())
+	if err != nil {
+		log.Printf("Failed to hash dummy password: %v", err)
+	}
+	return hash
+})
+
+// sendVerificationEmail stores a signed verification token and emails the verification link
+func (s *AuthService) sendVerificationEmail(user *models.User, language string) error {
+	token, err := utils.GenerateSignedToken()
+	if err != nil {
+		return err
+	}
+
+	// Store token in Redis with expiration
+	ctx := context.Background()
+	key := "verify_token:" + token
+	userData := map[string]string{
+		"user_id": user.ID.String(),
+		"email":   user.EmailAddress(),
+	}
+	if err := s.cache.SetJSON(ctx, key, userData, verificationTokenExpiration); err != nil {
+		return err
+	}
+
+	s.sendMail(user.EmailAddress(), "email_verification", language, map[string]string{
+		"name":    user.FullName,
+		"link":    frontendLink("/verify-email", token),
+		"minutes": strconv.Itoa(int(verificationTokenExpiration.Minutes())),
+	})
+
+	return nil
+}
+
+// loginRequiresVerifiedEmail reports whether unverified users are blocked from logging in
+func loginRequiresVerifiedEmail() bool {
+	return config.AppSetting.RequireEmailVerification == "login"
+}
+
+// enrollRequiresVerifiedEmail reports whether unverified users are blocked from enrolling in courses
+func enrollRequiresVerifiedEmail() bool {
+	switch config.AppSetting.RequireEmailVerification {
+	case "enroll", "login":
+		return true
+	}
+	return false
+}
+
+// sendMail renders a localized email template and delivers it in the background,
+// so that response times do not reveal whether an account exists
+func (s *AuthService) sendMail(to, template, language string, data map[string]string) {
+	msg, err := mailer.Render(template, language, data)
+	if err != nil {
+		log.Printf("Failed to render %s email: %v", template, err)
+		return
+	}
+	msg.To = to
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %s email: %v", template, err)
+		}
+	}()
+}
+
+// frontendLink builds a link to a frontend page carrying a token
+func frontendLink(path, token string) string {
+	base := strings.TrimRight(config.AppSetting.FrontendURL, "/")
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// beginSession logs a user in once the first factor has been checked. Users with
+// two-factor authentication get a short-lived challenge token instead of tokens.
+func (s *AuthService) beginSession(user *models.User, client ClientInfo) (*TokenResponse, error) {
+	if user.TOTPEnabledAt == nil {
+		return s.generateTokens(user, nil, client, false)
+	}
+
+	token, err := utils.GenerateSignedToken()
 	if err != nil {
 		return nil, err
 	}
 
+	// Only the hash of the challenge token is stored
+	ctx := context.Background()
+	challenge := mfaChallenge{
+		UserID:     user.ID,
+		DeviceName: client.DeviceName,
+	}
+	if err := s.cache.SetNonce(ctx, mfaChallengePurpose, utils.HashToken(token), challenge, mfaChallengeExpiration()); err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+// generateTokens generates access and refresh tokens. The refresh token starts a
+// new session (token family) unless it replaces a parent token. mfaVerified
+// records whether the session passed a second factor.
+func (s *AuthService) generateTokens(user *models.User, parent *models.RefreshToken, client ClientInfo, mfaVerified bool) (*TokenResponse, error) {
 	// Generate refresh token
 	refreshToken, expiresAt, err := utils.GenerateRefreshToken()
 	if err != nil {
@@ -258,26 +759,53 @@ func (s *AuthService) generateTokens(user *models.User) (*TokenResponse, error)
 
 	// Save refresh token
 	token := &models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: expiresAt,
+		UserID:      user.ID,
+		TokenHash:   utils.HashToken(refreshToken),
+		UserAgent:   client.UserAgent,
+		IPAddress:   client.IPAddress,
+		DeviceLabel: client.DeviceName,
+		LastUsedAt:  time.Now(),
+		ExpiresAt:   expiresAt,
+		MFAVerified: mfaVerified,
+	}
+	if parent != nil {
+		token.FamilyID = parent.FamilyID
+		token.ParentID = &parent.ID
+		if token.DeviceLabel == "" {
+			token.DeviceLabel = parent.DeviceLabel
+		}
+	}
+	if token.DeviceLabel == "" {
+		token.DeviceLabel = utils.DeviceLabel(client.UserAgent)
 	}
 
 	if err := s.refreshTokenRepo.Create(token); err != nil {
 		return nil, err
 	}
 
+	// Generate access token bound to the session
+	accessToken, err := utils.GenerateToken(user.ID, user.EmailAddress(), user.Role, token.FamilyID, token.MFAVerified)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create response
 	return &TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		ExpiresAt:    expiresAt,
-		User: UserResponse{
-			ID:             user.ID,
-			Email:          user.Email,
-			FullName:       user.FullName,
-			Role:           user.Role,
-			ProfilePicture: user.ProfilePicture,
-		},
+		User:         newUserResponse(user),
 	}, nil
 }
+
+// revokeFamily revokes every token of a family after a refresh token was replayed
+func (s *AuthService) revokeFamily(token *models.RefreshToken) {
+	log.Printf("SECURITY: refresh token reuse detected for user %s, revoking token family %s", token.UserID, token.FamilyID)
+
+	if err := s.tokenService.RevokeSession(token.FamilyID); err != nil {
+		log.Printf("Failed to revoke access tokens of family %s: %v", token.FamilyID, err)
+	}
+	if err := s.refreshTokenRepo.DeleteByFamilyID(token.FamilyID); err != nil {
+		log.Printf("Failed to revoke token family %s: %v", token.FamilyID, err)
+	}
+}
@@ -52,7 +52,7 @@ type UpdateCategoryRequest struct {
 }
 
 // Create creates a new category
-func (s *CategoryService) Create(req CreateCategoryRequest) (*CategoryResponse, error) {
+func (s *CategoryService) Create(req CreateCategoryRequest, audit *AuditRecord) (*CategoryResponse, error) {
 	// Generate slug if not provided
 	slug := req.Slug
 	if slug == "" {
@@ -69,12 +69,14 @@ func (s *CategoryService) Create(req CreateCategoryRequest) (*CategoryResponse,
 
 	// Create category
 	category := &models.Category{
+		ID:          uuid.New(),
 		Name:        req.Name,
 		Description: req.Description,
 		Slug:        slug,
 	}
 
-	if err := s.categoryRepo.Create(category); err != nil {
+	event := audit.Change(models.AuditActionCategoryCreate, models.AuditTargetCategory, category.ID, nil, newCategoryAuditState(category))
+	if err := s.categoryRepo.Create(category, event); err != nil {
 		return nil, err
 	}
 
@@ -133,7 +135,7 @@ func (s *CategoryService) GetBySlug(slug string) (*CategoryResponse, error) {
 }
 
 // Update updates a category
-func (s *CategoryService) Update(id uuid.UUID, req UpdateCategoryRequest) (*CategoryResponse, error) {
+func (s *CategoryService) Update(id uuid.UUID, req UpdateCategoryRequest, audit *AuditRecord) (*CategoryResponse, error) {
 	category, err := s.categoryRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -141,6 +143,7 @@ func (s *CategoryService) Update(id uuid.UUID, req UpdateCategoryRequest) (*Cate
 		}
 		return nil, err
 	}
+	before := newCategoryAuditState(category)
 
 	// Update fields
 	if req.Name != "" {
@@ -161,7 +164,8 @@ func (s *CategoryService) Update(id uuid.UUID, req UpdateCategoryRequest) (*Cate
 	}
 
 	// Save category
-	if err := s.categoryRepo.Update(category); err != nil {
+	event := audit.Change(models.AuditActionCategoryUpdate, models.AuditTargetCategory, category.ID, before, newCategoryAuditState(category))
+	if err := s.categoryRepo.Update(category, event); err != nil {
 		return nil, err
 	}
 
@@ -180,8 +184,8 @@ func (s *CategoryService) Update(id uuid.UUID, req UpdateCategoryRequest) (*Cate
 }
 
 // Delete deletes a category
-func (s *CategoryService) Delete(id uuid.UUID) error {
-	_, err := s.categoryRepo.GetByID(id)
+func (s *CategoryService) Delete(id uuid.UUID, audit *AuditRecord) error {
+	category, err := s.categoryRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("category not found")
@@ -189,7 +193,8 @@ func (s *CategoryService) Delete(id uuid.UUID) error {
 		return err
 	}
 
-	if err := s.categoryRepo.Delete(id); err != nil {
+	event := audit.Change(models.AuditActionCategoryDelete, models.AuditTargetCategory, category.ID, newCategoryAuditState(category), nil)
+	if err := s.categoryRepo.Delete(id, event); err != nil {
 		return err
 	}
 
@@ -235,6 +240,22 @@ func (s *CategoryService) List() ([]CategoryResponse, error) {
 	return categoryResponses, nil
 }
 
+// categoryAuditState is the state of a category recorded in the audit log
+type categoryAuditState struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Slug        string `json:"slug"`
+}
+
+// newCategoryAuditState takes the state of a category for the audit log
+func newCategoryAuditState(category *models.Category) categoryAuditState {
+	return categoryAuditState{
+		Name:        category.Name,
+		Description: category.Description,
+		Slug:        category.Slug,
+	}
+}
+
 // generateSlug generates a slug from a string
 func generateSlug(s string) string {
 	// Convert to lowercase
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/0xBoji/web3-edu-core/internal/database/redis"
@@ -12,38 +15,83 @@ import (
 	"gorm.io/gorm"
 )
 
+// courseListPrefix starts the cache keys of the course list pages
+const courseListPrefix = "courses:list:"
+
 type CourseService struct {
+	userRepo       *repositories.UserRepository
 	courseRepo     *repositories.CourseRepository
 	lessonRepo     *repositories.LessonRepository
 	enrollmentRepo *repositories.EnrollmentRepository
+	revisionRepo   *repositories.ContentRevisionRepository
+	roleService    *RoleService
 	cache          *redis.Cache
 }
 
 // NewCourseService creates a new course service
 func NewCourseService() *CourseService {
 	return &CourseService{
+		userRepo:       repositories.NewUserRepository(),
 		courseRepo:     repositories.NewCourseRepository(),
 		lessonRepo:     repositories.NewLessonRepository(),
 		enrollmentRepo: repositories.NewEnrollmentRepository(),
+		revisionRepo:   repositories.NewContentRevisionRepository(),
+		roleService:    NewRoleService(),
 		cache:          redis.NewCache(),
 	}
 }
 
+// courseAuditState is the state of a course recorded in the audit log
+type courseAuditState struct {
+	courseSnapshot
+	InstructorID uuid.UUID `json:"instructor_id"`
+	Status       string    `json:"status"`
+}
+
+// newCourseAuditState takes the state of a course for the audit log
+func newCourseAuditState(course *models.Course) courseAuditState {
+	return courseAuditState{
+		courseSnapshot: newCourseSnapshot(course),
+		InstructorID:   course.InstructorID,
+		Status:         course.Status,
+	}
+}
+
+// courseTransition is an allowed change of a course's status
+type courseTransition struct {
+	from, to string
+}
+
+// courseTransitions lists the allowed status changes. Those marked true need the
+// course:publish permission; the others can be made by the course instructor.
+var courseTransitions = map[courseTransition]bool{
+	{models.CourseStatusDraft, models.CourseStatusReview}:       false, // submit for review
+	{models.CourseStatusReview, models.CourseStatusDraft}:       false, // withdraw, or reject with a comment
+	{models.CourseStatusReview, models.CourseStatusPublished}:   true,  // approve
+	{models.CourseStatusPublished, models.CourseStatusArchived}: false, // archive
+	{models.CourseStatusArchived, models.CourseStatusPublished}: true,  // restore
+}
+
 // CourseResponse represents the course response
 type CourseResponse struct {
-	ID           uuid.UUID     `json:"id"`
-	Title        string        `json:"title"`
-	Description  string        `json:"description,omitempty"`
-	Thumbnail    string        `json:"thumbnail,omitempty"`
-	InstructorID uuid.UUID     `json:"instructor_id"`
-	Instructor   UserResponse  `json:"instructor,omitempty"`
-	Price        float64       `json:"price"`
-	Level        string        `json:"level,omitempty"`
-	Duration     int           `json:"duration,omitempty"`
-	Category     string        `json:"category,omitempty"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
-	Lessons      []LessonBrief `json:"lessons,omitempty"`
+	ID           uuid.UUID               `json:"id"`
+	Title        string                  `json:"title"`
+	Description  string                  `json:"description,omitempty"`
+	Thumbnail    string                  `json:"thumbnail,omitempty"`
+	InstructorID uuid.UUID               `json:"instructor_id"`
+	Instructor   UserResponse            `json:"instructor,omitempty"`
+	Price        float64                 `json:"price"`
+	Level        string                  `json:"level,omitempty"`
+	Duration     int                     `json:"duration,omitempty"`
+	Category     string                  `json:"category,omitempty"`
+	RatingAvg    float64                 `json:"rating_avg"`
+	RatingCount  int                     `json:"rating_count"`
+	Status       string                  `json:"status"`
+	PublishedAt  *time.Time              `json:"published_at,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+	Lessons      []LessonBrief           `json:"lessons,omitempty"`
+	Progress     *CourseProgressResponse `json:"progress,omitempty"`
 }
 
 // LessonBrief represents a brief version of a lesson
@@ -55,12 +103,13 @@ type LessonBrief struct {
 	OrderNumber int       `json:"order_number"`
 }
 
-// CreateCourseRequest represents the create course request
+// CreateCourseRequest represents the create course request. Instructors always
+// create their own courses; admins may name another instructor.
 type CreateCourseRequest struct {
 	Title        string    `json:"title" binding:"required"`
 	Description  string    `json:"description"`
 	Thumbnail    string    `json:"thumbnail"`
-	InstructorID uuid.UUID `json:"instructor_id" binding:"required"`
+	InstructorID uuid.UUID `json:"instructor_id"`
 	Price        float64   `json:"price"`
 	Level        string    `json:"level"`
 	Duration     int       `json:"duration"`
@@ -78,33 +127,58 @@ type UpdateCourseRequest struct {
 	Category    string  `json:"category"`
 }
 
-// Create creates a new course
-func (s *CourseService) Create(req CreateCourseRequest) (*CourseResponse, error) {
+// CourseStatusRequest represents the change course status request
+type CourseStatusRequest struct {
+	Status  string `json:"status" binding:"required,oneof=draft review published archived"`
+	Comment string `json:"comment" binding:"max=5000"`
+}
+
+// CourseStatusChangeResponse represents a recorded change of a course's status
+type CourseStatusChangeResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	OldStatus string     `json:"old_status"`
+	NewStatus string     `json:"new_status"`
+	ChangedBy *uuid.UUID `json:"changed_by,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Create creates a new draft course
+func (s *CourseService) Create(userID uuid.UUID, role string, req CreateCourseRequest, audit *AuditRecord) (*CourseResponse, error) {
+	instructorID := req.InstructorID
+	if role != "admin" || instructorID == uuid.Nil {
+		instructorID = userID
+	}
+
 	course := &models.Course{
+		ID:           uuid.New(),
 		Title:        req.Title,
 		Description:  req.Description,
 		Thumbnail:    req.Thumbnail,
-		InstructorID: req.InstructorID,
+		InstructorID: instructorID,
 		Price:        req.Price,
 		Level:        req.Level,
 		Duration:     req.Duration,
 		Category:     req.Category,
+		Status:       models.CourseStatusDraft,
 	}
 
-	if err := s.courseRepo.Create(course); err != nil {
+	revision, err := newRevision(models.RevisionEntityCourse, models.RevisionActionCreate, userID, newCourseSnapshot(course))
+	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate cache
-	ctx := context.Background()
-	s.cache.Delete(ctx, "courses:list")
-	s.cache.Delete(ctx, "courses:featured")
+	event := audit.Change(models.AuditActionCourseCreate, models.AuditTargetCourse, course.ID, nil, newCourseAuditState(course))
+	if err := s.courseRepo.Create(course, revision, event); err != nil {
+		return nil, err
+	}
 
 	return s.mapCourseToResponse(course), nil
 }
 
-// GetByID gets a course by ID
-func (s *CourseService) GetByID(id uuid.UUID) (*CourseResponse, error) {
+// GetByID gets a published or archived course by ID. Its instructor and admins
+// can also preview it while it is a draft or in review.
+func (s *CourseService) GetByID(id, viewerID uuid.UUID, role string) (*CourseResponse, error) {
 	// Try to get from cache
 	ctx := context.Background()
 	var cachedCourse CourseResponse
@@ -114,7 +188,10 @@ func (s *CourseService) GetByID(id uuid.UUID) (*CourseResponse, error) {
 	}
 
 	// Get from database
-	course, err := s.courseRepo.GetByIDWithLessons(id)
+	course, err := s.courseRepo.GetPublicByIDWithLessons(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) && viewerID != uuid.Nil {
+		return s.preview(id, viewerID, role)
+	}
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("course not found")
@@ -130,8 +207,56 @@ func (s *CourseService) GetByID(id uuid.UUID) (*CourseResponse, error) {
 	return response, nil
 }
 
-// Update updates a course
-func (s *CourseService) Update(id uuid.UUID, req UpdateCourseRequest) (*CourseResponse, error) {
+// preview gets an unpublished course for its instructor or an admin. Previews
+// are not cached.
+func (s *CourseService) preview(id, viewerID uuid.UUID, role string) (*CourseResponse, error) {
+	course, err := s.courseRepo.GetByIDWithLessons(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("course not found")
+		}
+		return nil, err
+	}
+
+	if role != "admin" && course.InstructorID != viewerID {
+		return nil, errors.New("course not found")
+	}
+
+	return s.mapCourseToResponse(course), nil
+}
+
+// ListManaged lists courses in any status, optionally filtered by status.
+// Admins see all courses, instructors only their own.
+func (s *CourseService) ListManaged(userID uuid.UUID, role, status string, page, pageSize int) ([]CourseResponse, int64, error) {
+	switch status {
+	case "", models.CourseStatusDraft, models.CourseStatusReview, models.CourseStatusPublished, models.CourseStatusArchived:
+	default:
+		return nil, 0, errors.New("invalid course status")
+	}
+
+	instructorID := userID
+	if role == "admin" {
+		instructorID = uuid.Nil
+	}
+
+	courses, count, err := s.courseRepo.ListManaged(instructorID, status, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	courseResponses := []CourseResponse{}
+	for _, course := range courses {
+		courseResponses = append(courseResponses, *s.mapCourseToResponse(&course))
+	}
+
+	return courseResponses, count, nil
+}
+
+// ChangeStatus moves a course through the publishing workflow. Instructors
+// submit drafts for review, withdraw them and archive published courses;
+// approving, rejecting and restoring need the course:publish permission.
+// Rejections must explain what to change in the comment.
+func (s *CourseService) ChangeStatus(id, userID uuid.UUID, role string, req CourseStatusRequest, audit *AuditRecord) (*CourseResponse, error) {
 	course, err := s.courseRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -140,6 +265,98 @@ func (s *CourseService) Update(id uuid.UUID, req UpdateCourseRequest) (*CourseRe
 		return nil, err
 	}
 
+	canPublish, err := s.roleService.HasPermission(role, "course:publish")
+	if err != nil {
+		return nil, err
+	}
+	if !canPublish && course.InstructorID != userID {
+		return nil, errors.New("you are not the instructor of this course")
+	}
+
+	needsPublish, allowed := courseTransitions[courseTransition{course.Status, req.Status}]
+	if !allowed {
+		return nil, errors.New("cannot change course status from " + course.Status + " to " + req.Status)
+	}
+	if needsPublish && !canPublish {
+		return nil, errors.New("you are not allowed to publish courses")
+	}
+
+	comment := strings.TrimSpace(req.Comment)
+	rejected := course.Status == models.CourseStatusReview && req.Status == models.CourseStatusDraft && course.InstructorID != userID
+	if rejected && comment == "" {
+		return nil, errors.New("a comment is required to reject a course")
+	}
+
+	// The publication time is kept when an archived course is restored
+	var publishedAt *time.Time
+	if req.Status == models.CourseStatusPublished && course.PublishedAt == nil {
+		now := time.Now()
+		publishedAt = &now
+	}
+
+	change := &models.CourseStatusChange{
+		CourseID:  course.ID,
+		OldStatus: course.Status,
+		NewStatus: req.Status,
+		ChangedBy: &userID,
+		Comment:   comment,
+	}
+	event := audit.Change(models.AuditActionCourseStatusChange, models.AuditTargetCourse, course.ID,
+		map[string]string{"status": change.OldStatus},
+		map[string]string{"status": change.NewStatus, "comment": change.Comment},
+	)
+	if err := s.courseRepo.UpdateStatus(change, publishedAt, event); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("course status was changed by another request")
+		}
+		return nil, err
+	}
+
+	course.Status = req.Status
+	if publishedAt != nil {
+		course.PublishedAt = publishedAt
+	}
+
+	// Invalidate cache
+	invalidateCourses(s.cache, id)
+
+	return s.mapCourseToResponse(course), nil
+}
+
+// GetStatusHistory lists the status changes of a course, newest first
+func (s *CourseService) GetStatusHistory(id, userID uuid.UUID, role string) ([]CourseStatusChangeResponse, error) {
+	if _, err := checkCourseInstructor(s.courseRepo, id, userID, role); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.courseRepo.GetStatusChanges(id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]CourseStatusChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		responses = append(responses, CourseStatusChangeResponse{
+			ID:        change.ID,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+			ChangedBy: change.ChangedBy,
+			Comment:   change.Comment,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+
+	return responses, nil
+}
+
+// Update updates a course. Instructors may only update their own courses.
+func (s *CourseService) Update(id, userID uuid.UUID, role string, req UpdateCourseRequest, audit *AuditRecord) (*CourseResponse, error) {
+	course, err := checkCourseInstructor(s.courseRepo, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	before := newCourseAuditState(course)
+
 	// Update fields
 	if req.Title != "" {
 		course.Title = req.Title
@@ -163,39 +380,78 @@ func (s *CourseService) Update(id uuid.UUID, req UpdateCourseRequest) (*CourseRe
 		course.Category = req.Category
 	}
 
-	// Save course
-	if err := s.courseRepo.Update(course); err != nil {
+	// Save course with a revision of its new content
+	revision, err := newRevision(models.RevisionEntityCourse, models.RevisionActionUpdate, userID, newCourseSnapshot(course))
+	if err != nil {
+		return nil, err
+	}
+	event := audit.Change(models.AuditActionCourseUpdate, models.AuditTargetCourse, course.ID, before, newCourseAuditState(course))
+	if err := s.courseRepo.Update(course, revision, event); err != nil {
 		return nil, err
 	}
 
 	// Invalidate cache
-	ctx := context.Background()
-	s.cache.Delete(ctx, "course:"+id.String())
-	s.cache.Delete(ctx, "courses:list")
-	s.cache.Delete(ctx, "courses:featured")
+	invalidateCourses(s.cache, id)
 
 	return s.mapCourseToResponse(course), nil
 }
 
-// Delete deletes a course
-func (s *CourseService) Delete(id uuid.UUID) error {
-	_, err := s.courseRepo.GetByID(id)
+// Rollback restores the content of a course from an earlier revision. The
+// rollback is recorded as a new revision, so it can be rolled back in turn.
+func (s *CourseService) Rollback(id, userID uuid.UUID, role string, version int, audit *AuditRecord) (*CourseResponse, error) {
+	course, err := checkCourseInstructor(s.courseRepo, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	before := newCourseAuditState(course)
+
+	target, err := getRevision(s.revisionRepo, models.RevisionEntityCourse, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot courseSnapshot
+	if err := json.Unmarshal(target.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+	snapshot.applyTo(course)
+
+	revision, err := newRevision(models.RevisionEntityCourse, models.RevisionActionRollback, userID, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	revision.RestoredVersion = &version
+
+	event := audit.Change(models.AuditActionCourseRollback, models.AuditTargetCourse, course.ID, before, newCourseAuditState(course))
+	if err := s.courseRepo.Update(course, revision, event); err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	invalidateCourses(s.cache, id)
+
+	return s.mapCourseToResponse(course), nil
+}
+
+// Delete deletes a course. Instructors may only delete their own drafts;
+// published courses are archived instead.
+func (s *CourseService) Delete(id, userID uuid.UUID, role string, audit *AuditRecord) error {
+	course, err := checkCourseInstructor(s.courseRepo, id, userID, role)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("course not found")
-		}
 		return err
 	}
 
-	if err := s.courseRepo.Delete(id); err != nil {
+	if role != "admin" && course.Status != models.CourseStatusDraft {
+		return errors.New("only draft courses can be deleted")
+	}
+
+	event := audit.Change(models.AuditActionCourseDelete, models.AuditTargetCourse, course.ID, newCourseAuditState(course), nil)
+	if err := s.courseRepo.Delete(id, event); err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	ctx := context.Background()
-	s.cache.Delete(ctx, "course:"+id.String())
-	s.cache.Delete(ctx, "courses:list")
-	s.cache.Delete(ctx, "courses:featured")
+	invalidateCourses(s.cache, id)
 
 	return nil
 }
@@ -209,7 +465,7 @@ func (s *CourseService) List(page, pageSize int, category string) ([]CourseRespo
 			Courses []CourseResponse `json:"courses"`
 			Total   int64            `json:"total"`
 		}
-		cacheKey := "courses:list:page:" + string(rune(page)) + ":size:" + string(rune(pageSize))
+		cacheKey := courseListKey(page, pageSize)
 		err := s.cache.GetJSON(ctx, cacheKey, &cachedCourses)
 		if err == nil {
 			return cachedCourses.Courses, cachedCourses.Total, nil
@@ -240,7 +496,7 @@ func (s *CourseService) List(page, pageSize int, category string) ([]CourseRespo
 	// Cache the result if no filters
 	if category == "" {
 		ctx := context.Background()
-		cacheKey := "courses:list:page:" + string(rune(page)) + ":size:" + string(rune(pageSize))
+		cacheKey := courseListKey(page, pageSize)
 		s.cache.SetJSON(ctx, cacheKey, struct {
 			Courses []CourseResponse `json:"courses"`
 			Total   int64            `json:"total"`
@@ -253,6 +509,22 @@ func (s *CourseService) List(page, pageSize int, category string) ([]CourseRespo
 	return courseResponses, count, nil
 }
 
+// courseListKey is the cache key of a page of the course list. Every page
+// starts with courseListPrefix, so that invalidateCourses drops them all.
+func courseListKey(page, pageSize int) string {
+	return courseListPrefix + "page:" + strconv.Itoa(page) + ":size:" + strconv.Itoa(pageSize)
+}
+
+// invalidateCourses drops the cached copies of courses and the course lists
+func invalidateCourses(cache *redis.Cache, courseIDs ...uuid.UUID) {
+	ctx := context.Background()
+	for _, courseID := range courseIDs {
+		cache.Delete(ctx, "course:"+courseID.String())
+	}
+	cache.DeleteByPrefix(ctx, courseListPrefix)
+	cache.Delete(ctx, "courses:featured")
+}
+
 // GetFeatured gets featured courses
 func (s *CourseService) GetFeatured() ([]CourseResponse, error) {
 	// Try to get from cache
@@ -281,8 +553,12 @@ func (s *CourseService) GetFeatured() ([]CourseResponse, error) {
 	return courseResponses, nil
 }
 
-// GetLessons gets lessons for a course
-func (s *CourseService) GetLessons(courseID uuid.UUID) ([]LessonBrief, error) {
+// GetLessons gets lessons for a course the viewer may see
+func (s *CourseService) GetLessons(courseID, viewerID uuid.UUID, role string) ([]LessonBrief, error) {
+	if _, err := s.GetByID(courseID, viewerID, role); err != nil {
+		return nil, err
+	}
+
 	lessons, err := s.lessonRepo.GetByCourseID(courseID)
 	if err != nil {
 		return nil, err
@@ -304,6 +580,18 @@ func (s *CourseService) GetLessons(courseID uuid.UUID) ([]LessonBrief, error) {
 
 // Enroll enrolls a user in a course
 func (s *CourseService) Enroll(userID, courseID uuid.UUID) error {
+	// Check email verification
+	if enrollRequiresVerifiedEmail() {
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return err
+		}
+		// Wallet-only accounts have proven their identity by signing in
+		if user.Email != nil && user.EmailVerifiedAt == nil {
+			return errors.New("email address is not verified")
+		}
+	}
+
 	// Check if already enrolled
 	enrolled, err := s.enrollmentRepo.IsEnrolled(userID, courseID)
 	if err != nil {
@@ -313,14 +601,21 @@ func (s *CourseService) Enroll(userID, courseID uuid.UUID) error {
 		return errors.New("already enrolled in this course")
 	}
 
-	// Check if course exists
-	_, err = s.courseRepo.GetByID(courseID)
+	// Check if course exists and is open for enrollment
+	course, err := s.courseRepo.GetByID(courseID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("course not found")
 		}
 		return err
 	}
+	switch course.Status {
+	case models.CourseStatusPublished:
+	case models.CourseStatusArchived:
+		return errors.New("course is not open for enrollment")
+	default:
+		return errors.New("course not found")
+	}
 
 	// Create enrollment
 	enrollment := &models.Enrollment{
@@ -331,6 +626,24 @@ func (s *CourseService) Enroll(userID, courseID uuid.UUID) error {
 	return s.enrollmentRepo.Create(enrollment)
 }
 
+// checkCourseInstructor checks that the course exists and that the user may manage it.
+// Admins may manage any course, instructors only the courses they teach.
+func checkCourseInstructor(courseRepo *repositories.CourseRepository, courseID, userID uuid.UUID, role string) (*models.Course, error) {
+	course, err := courseRepo.GetByID(courseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("course not found")
+		}
+		return nil, err
+	}
+
+	if role != "admin" && course.InstructorID != userID {
+		return nil, errors.New("you are not the instructor of this course")
+	}
+
+	return course, nil
+}
+
 // mapCourseToResponse maps a course model to a course response
 func (s *CourseService) mapCourseToResponse(course *models.Course) *CourseResponse {
 	response := &CourseResponse{
@@ -343,18 +656,16 @@ func (s *CourseService) mapCourseToResponse(course *models.Course) *CourseRespon
 		Level:        course.Level,
 		Duration:     course.Duration,
 		Category:     course.Category,
+		RatingAvg:    course.RatingAvg,
+		RatingCount:  course.RatingCount,
+		Status:       course.Status,
+		PublishedAt:  course.PublishedAt,
 		CreatedAt:    course.CreatedAt,
 		UpdatedAt:    course.UpdatedAt,
 	}
 
 	if course.Instructor.ID != uuid.Nil {
-		response.Instructor = UserResponse{
-			ID:             course.Instructor.ID,
-			Email:          course.Instructor.Email,
-			FullName:       course.Instructor.FullName,
-			Role:           course.Instructor.Role,
-			ProfilePicture: course.Instructor.ProfilePicture,
-		}
+		response.Instructor = newUserResponse(&course.Instructor)
 	}
 
 	if course.Lessons != nil {
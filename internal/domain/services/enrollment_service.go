@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// rosterExportPageSize is the number of enrollments loaded per query when exporting a roster
+const rosterExportPageSize = 500
+
+type EnrollmentService struct {
+	enrollmentRepo  *repositories.EnrollmentRepository
+	courseRepo      *repositories.CourseRepository
+	lessonRepo      *repositories.LessonRepository
+	courseService   *CourseService
+	progressService *ProgressService
+	cache           *redis.Cache
+}
+
+// NewEnrollmentService creates a new enrollment service
+func NewEnrollmentService() *EnrollmentService {
+	return &EnrollmentService{
+		enrollmentRepo:  repositories.NewEnrollmentRepository(),
+		courseRepo:      repositories.NewCourseRepository(),
+		lessonRepo:      repositories.NewLessonRepository(),
+		courseService:   NewCourseService(),
+		progressService: NewProgressService(),
+		cache:           redis.NewCache(),
+	}
+}
+
+// EnrollmentResponse represents an enrollment of the current user
+type EnrollmentResponse struct {
+	ID         uuid.UUID               `json:"id"`
+	CourseID   uuid.UUID               `json:"course_id"`
+	Course     CourseResponse          `json:"course"`
+	EnrolledAt time.Time               `json:"enrolled_at"`
+	Progress   *CourseProgressResponse `json:"progress,omitempty"`
+}
+
+// RosterEntryResponse represents a learner enrolled in a course
+type RosterEntryResponse struct {
+	ID               uuid.UUID    `json:"id"`
+	User             UserResponse `json:"user"`
+	EnrolledAt       time.Time    `json:"enrolled_at"`
+	CompletedLessons int          `json:"completed_lessons"`
+	TotalLessons     int          `json:"total_lessons"`
+	Percentage       float64      `json:"percentage"`
+}
+
+// CreateEnrollmentRequest represents the create enrollment request
+type CreateEnrollmentRequest struct {
+	CourseID uuid.UUID `json:"course_id" binding:"required"`
+}
+
+// ListByUser lists the enrollments of a user with course summaries and progress
+func (s *EnrollmentService) ListByUser(userID uuid.UUID, page, pageSize int) ([]EnrollmentResponse, int64, error) {
+	enrollments, count, err := s.enrollmentRepo.GetByUserID(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var enrollmentResponses []EnrollmentResponse
+	for _, enrollment := range enrollments {
+		response := EnrollmentResponse{
+			ID:         enrollment.ID,
+			CourseID:   enrollment.CourseID,
+			Course:     *s.courseService.mapCourseToResponse(&enrollment.Course),
+			EnrolledAt: enrollment.EnrolledAt,
+		}
+
+		lessons, err := s.lessonRepo.GetByCourseID(enrollment.CourseID)
+		if err != nil {
+			return nil, 0, err
+		}
+		progress, err := s.progressService.summarizeCourse(userID, enrollment.CourseID, lessons)
+		if err != nil {
+			return nil, 0, err
+		}
+		response.Progress = progress
+
+		enrollmentResponses = append(enrollmentResponses, response)
+	}
+
+	return enrollmentResponses, count, nil
+}
+
+// Create enrolls a user in a course
+func (s *EnrollmentService) Create(userID uuid.UUID, req CreateEnrollmentRequest) (*EnrollmentResponse, error) {
+	if err := s.courseService.Enroll(userID, req.CourseID); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourseID(userID, req.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnrollmentResponse{
+		ID:         enrollment.ID,
+		CourseID:   enrollment.CourseID,
+		Course:     *s.courseService.mapCourseToResponse(&enrollment.Course),
+		EnrolledAt: enrollment.EnrolledAt,
+	}, nil
+}
+
+// Delete removes an enrollment. Learners may only remove their own enrollments.
+func (s *EnrollmentService) Delete(id, userID uuid.UUID, role string) error {
+	enrollment, err := s.enrollmentRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("enrollment not found")
+		}
+		return err
+	}
+
+	if role != "admin" && enrollment.UserID != userID {
+		return errors.New("enrollment not found")
+	}
+
+	if err := s.enrollmentRepo.Delete(id); err != nil {
+		return err
+	}
+
+	// Drop buffered progress for the course
+	ctx := context.Background()
+	s.cache.DeleteUserProgress(ctx, enrollment.UserID.String(), enrollment.CourseID.String())
+
+	return nil
+}
+
+// ListByCourse lists the roster of a course. Instructors may only list their own courses.
+func (s *EnrollmentService) ListByCourse(courseID, userID uuid.UUID, role string, page, pageSize int) ([]RosterEntryResponse, int64, error) {
+	if _, err := checkCourseInstructor(s.courseRepo, courseID, userID, role); err != nil {
+		return nil, 0, err
+	}
+
+	enrollments, count, err := s.enrollmentRepo.GetByCourseID(courseID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lessons, err := s.lessonRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	roster, err := s.mapRoster(courseID, enrollments, lessons)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return roster, count, nil
+}
+
+// ExportRosterCSV writes the full roster of a course as CSV
+func (s *EnrollmentService) ExportRosterCSV(courseID, userID uuid.UUID, role string, w io.Writer) error {
+	if _, err := checkCourseInstructor(s.courseRepo, courseID, userID, role); err != nil {
+		return err
+	}
+
+	lessons, err := s.lessonRepo.GetByCourseID(courseID)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"enrollment_id", "user_id", "email", "full_name", "enrolled_at",
+		"completed_lessons", "total_lessons", "percentage",
+	}); err != nil {
+		return err
+	}
+
+	for page := 1; ; page++ {
+		enrollments, _, err := s.enrollmentRepo.GetByCourseID(courseID, page, rosterExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		roster, err := s.mapRoster(courseID, enrollments, lessons)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range roster {
+			if err := writer.Write([]string{
+				entry.ID.String(),
+				entry.User.ID.String(),
+				csvText(entry.User.Email),
+				csvText(entry.User.FullName),
+				entry.EnrolledAt.Format(time.RFC3339),
+				strconv.Itoa(entry.CompletedLessons),
+				strconv.Itoa(entry.TotalLessons),
+				strconv.FormatFloat(entry.Percentage, 'f', 2, 64),
+			}); err != nil {
+				return err
+			}
+		}
+
+		if len(enrollments) < rosterExportPageSize {
+			break
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText escapes user-controlled text for a CSV cell. Spreadsheets evaluate
+// cells starting with =, +, -, @, a tab or a carriage return as formulas, so
+// such cells are prefixed with a quote to be shown as text.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// mapRoster maps course enrollments to roster entries with progress
+func (s *EnrollmentService) mapRoster(courseID uuid.UUID, enrollments []models.Enrollment, lessons []models.Lesson) ([]RosterEntryResponse, error) {
+	var roster []RosterEntryResponse
+	for _, enrollment := range enrollments {
+		progress, err := s.progressService.summarizeCourse(enrollment.UserID, courseID, lessons)
+		if err != nil {
+			return nil, err
+		}
+
+		roster = append(roster, RosterEntryResponse{
+			ID:               enrollment.ID,
+			User:             newUserResponse(&enrollment.User),
+			EnrolledAt:       enrollment.EnrolledAt,
+			CompletedLessons: progress.CompletedLessons,
+			TotalLessons:     progress.TotalLessons,
+			Percentage:       progress.Percentage,
+		})
+	}
+	return roster, nil
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LessonService struct {
+	lessonRepo   *repositories.LessonRepository
+	courseRepo   *repositories.CourseRepository
+	revisionRepo *repositories.ContentRevisionRepository
+	cache        *redis.Cache
+}
+
+// NewLessonService creates a new lesson service
+func NewLessonService() *LessonService {
+	return &LessonService{
+		lessonRepo:   repositories.NewLessonRepository(),
+		courseRepo:   repositories.NewCourseRepository(),
+		revisionRepo: repositories.NewContentRevisionRepository(),
+		cache:        redis.NewCache(),
+	}
+}
+
+// LessonResponse represents the lesson response
+type LessonResponse struct {
+	ID          uuid.UUID `json:"id"`
+	CourseID    uuid.UUID `json:"course_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	VideoURL    string    `json:"video_url"`
+	VideoID     string    `json:"video_id"`
+	Duration    int       `json:"duration,omitempty"`
+	OrderNumber int       `json:"order_number"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateLessonRequest represents the create lesson request
+type CreateLessonRequest struct {
+	CourseID    uuid.UUID `json:"course_id" binding:"required"`
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	VideoURL    string    `json:"video_url" binding:"required"`
+	Duration    int       `json:"duration"`
+	OrderNumber int       `json:"order_number" binding:"required,min=1"`
+}
+
+// UpdateLessonRequest represents the update lesson request
+type UpdateLessonRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	VideoURL    string `json:"video_url"`
+	Duration    int    `json:"duration"`
+	OrderNumber int    `json:"order_number" binding:"omitempty,min=1"`
+}
+
+// GetByID gets a lesson by ID. Lessons of courses that are not published or
+// archived are only shown to the course instructor and admins.
+func (s *LessonService) GetByID(id, viewerID uuid.UUID, role string) (*LessonResponse, error) {
+	lesson, err := s.lessonRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson not found")
+		}
+		return nil, err
+	}
+
+	course, err := s.courseRepo.GetByID(lesson.CourseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson not found")
+		}
+		return nil, err
+	}
+
+	switch course.Status {
+	case models.CourseStatusPublished, models.CourseStatusArchived:
+	default:
+		if role != "admin" && course.InstructorID != viewerID {
+			return nil, errors.New("lesson not found")
+		}
+	}
+
+	return s.mapLessonToResponse(lesson), nil
+}
+
+// Create creates a new lesson
+func (s *LessonService) Create(userID uuid.UUID, role string, req CreateLessonRequest, audit *AuditRecord) (*LessonResponse, error) {
+	// Check that the caller may manage the course
+	if err := s.checkCourseAccess(req.CourseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	// Check that the order number is free within the course
+	if err := s.checkOrderNumber(req.CourseID, req.OrderNumber, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	// Extract video ID from URL
+	videoID, err := utils.ExtractYouTubeID(req.VideoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	lesson := &models.Lesson{
+		ID:          uuid.New(),
+		CourseID:    req.CourseID,
+		Title:       req.Title,
+		Description: req.Description,
+		VideoURL:    req.VideoURL,
+		VideoID:     videoID,
+		Duration:    req.Duration,
+		OrderNumber: req.OrderNumber,
+	}
+
+	revision, err := newRevision(models.RevisionEntityLesson, models.RevisionActionCreate, userID, newLessonSnapshot(lesson))
+	if err != nil {
+		return nil, err
+	}
+
+	event := audit.Change(models.AuditActionLessonCreate, models.AuditTargetLesson, lesson.ID, nil, newLessonAuditState(lesson))
+	if err := s.lessonRepo.Create(lesson, revision, event); err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	ctx := context.Background()
+	s.cache.Delete(ctx, "course:"+lesson.CourseID.String())
+
+	return s.mapLessonToResponse(lesson), nil
+}
+
+// Update updates a lesson
+func (s *LessonService) Update(id, userID uuid.UUID, role string, req UpdateLessonRequest, audit *AuditRecord) (*LessonResponse, error) {
+	lesson, err := s.lessonRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson not found")
+		}
+		return nil, err
+	}
+
+	// Check that the caller may manage the course
+	if err := s.checkCourseAccess(lesson.CourseID, userID, role); err != nil {
+		return nil, err
+	}
+	before := newLessonAuditState(lesson)
+
+	// Update fields
+	if req.Title != "" {
+		lesson.Title = req.Title
+	}
+	if req.Description != "" {
+		lesson.Description = req.Description
+	}
+	if req.VideoURL != "" {
+		videoID, err := utils.ExtractYouTubeID(req.VideoURL)
+		if err != nil {
+			return nil, err
+		}
+		lesson.VideoURL = req.VideoURL
+		lesson.VideoID = videoID
+	}
+	if req.Duration != 0 {
+		lesson.Duration = req.Duration
+	}
+	if req.OrderNumber != 0 && req.OrderNumber != lesson.OrderNumber {
+		if err := s.checkOrderNumber(lesson.CourseID, req.OrderNumber, lesson.ID); err != nil {
+			return nil, err
+		}
+		lesson.OrderNumber = req.OrderNumber
+	}
+
+	// Save lesson with a revision of its new content
+	revision, err := newRevision(models.RevisionEntityLesson, models.RevisionActionUpdate, userID, newLessonSnapshot(lesson))
+	if err != nil {
+		return nil, err
+	}
+	event := audit.Change(models.AuditActionLessonUpdate, models.AuditTargetLesson, lesson.ID, before, newLessonAuditState(lesson))
+	if err := s.lessonRepo.Update(lesson, revision, event); err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	ctx := context.Background()
+	s.cache.Delete(ctx, "course:"+lesson.CourseID.String())
+
+	return s.mapLessonToResponse(lesson), nil
+}
+
+// Rollback restores the content of a lesson from an earlier revision. The
+// rollback is recorded as a new revision, so it can be rolled back in turn.
+func (s *LessonService) Rollback(id, userID uuid.UUID, role string, version int, audit *AuditRecord) (*LessonResponse, error) {
+	lesson, err := s.lessonRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson not found")
+		}
+		return nil, err
+	}
+
+	// Check that the caller may manage the course
+	if err := s.checkCourseAccess(lesson.CourseID, userID, role); err != nil {
+		return nil, err
+	}
+	before := newLessonAuditState(lesson)
+
+	target, err := getRevision(s.revisionRepo, models.RevisionEntityLesson, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot lessonSnapshot
+	if err := json.Unmarshal(target.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	// Another lesson may have taken the old position since
+	if snapshot.OrderNumber != lesson.OrderNumber {
+		if err := s.checkOrderNumber(lesson.CourseID, snapshot.OrderNumber, lesson.ID); err != nil {
+			return nil, err
+		}
+	}
+	snapshot.applyTo(lesson)
+
+	revision, err := newRevision(models.RevisionEntityLesson, models.RevisionActionRollback, userID, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	revision.RestoredVersion = &version
+
+	event := audit.Change(models.AuditActionLessonRollback, models.AuditTargetLesson, lesson.ID, before, newLessonAuditState(lesson))
+	if err := s.lessonRepo.Update(lesson, revision, event); err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	ctx := context.Background()
+	s.cache.Delete(ctx, "course:"+lesson.CourseID.String())
+
+	return s.mapLessonToResponse(lesson), nil
+}
+
+// Delete deletes a lesson
+func (s *LessonService) Delete(id, userID uuid.UUID, role string, audit *AuditRecord) error {
+	lesson, err := s.lessonRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("lesson not found")
+		}
+		return err
+	}
+
+	// Check that the caller may manage the course
+	if err := s.checkCourseAccess(lesson.CourseID, userID, role); err != nil {
+		return err
+	}
+
+	event := audit.Change(models.AuditActionLessonDelete, models.AuditTargetLesson, lesson.ID, newLessonAuditState(lesson), nil)
+	if err := s.lessonRepo.Delete(id, event); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	ctx := context.Background()
+	s.cache.Delete(ctx, "course:"+lesson.CourseID.String())
+
+	return nil
+}
+
+// lessonAuditState is the state of a lesson recorded in the audit log
+type lessonAuditState struct {
+	lessonSnapshot
+	CourseID uuid.UUID `json:"course_id"`
+}
+
+// newLessonAuditState takes the state of a lesson for the audit log
+func newLessonAuditState(lesson *models.Lesson) lessonAuditState {
+	return lessonAuditState{
+		lessonSnapshot: newLessonSnapshot(lesson),
+		CourseID:       lesson.CourseID,
+	}
+}
+
+// checkCourseAccess checks that the course exists and that the user may manage it
+func (s *LessonService) checkCourseAccess(courseID, userID uuid.UUID, role string) error {
+	_, err := checkCourseInstructor(s.courseRepo, courseID, userID, role)
+	return err
+}
+
+// checkOrderNumber checks that no other lesson in the course uses the order number
+func (s *LessonService) checkOrderNumber(courseID uuid.UUID, orderNumber int, lessonID uuid.UUID) error {
+	existingLesson, err := s.lessonRepo.GetByCourseAndOrderNumber(courseID, orderNumber)
+	if err == nil && existingLesson.ID != lessonID {
+		return errors.New("order number already exists in this course")
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// mapLessonToResponse maps a lesson model to a lesson response
+func (s *LessonService) mapLessonToResponse(lesson *models.Lesson) *LessonResponse {
+	return &LessonResponse{
+		ID:          lesson.ID,
+		CourseID:    lesson.CourseID,
+		Title:       lesson.Title,
+		Description: lesson.Description,
+		VideoURL:    lesson.VideoURL,
+		VideoID:     lesson.VideoID,
+		Duration:    lesson.Duration,
+		OrderNumber: lesson.OrderNumber,
+		CreatedAt:   lesson.CreatedAt,
+		UpdatedAt:   lesson.UpdatedAt,
+	}
+}
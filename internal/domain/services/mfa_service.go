@@ -0,0 +1,387 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// mfaChallengePurpose is the nonce purpose of login challenges waiting for a second factor
+	mfaChallengePurpose = "mfa"
+	// mfaMaxAttempts is how many codes may be tried against one login challenge
+	mfaMaxAttempts = 5
+	// totpSetupExpiration is how long a generated secret waits to be confirmed
+	totpSetupExpiration = 10 * time.Minute
+	// totpReplayWindow covers every code accepted with one period of clock skew
+	totpReplayWindow = 90 * time.Second
+	// recoveryCodeCount is how many recovery codes are issued at a time
+	recoveryCodeCount = 10
+)
+
+// MFAService handles TOTP two-factor authentication
+type MFAService struct {
+	userRepo         *repositories.UserRepository
+	recoveryCodeRepo *repositories.MFARecoveryCodeRepository
+	refreshTokenRepo *repositories.RefreshTokenRepository
+	authService      *AuthService
+	cache            *redis.Cache
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService() *MFAService {
+	return &MFAService{
+		userRepo:         repositories.NewUserRepository(),
+		recoveryCodeRepo: repositories.NewMFARecoveryCodeRepository(),
+		refreshTokenRepo: repositories.NewRefreshTokenRepository(),
+		authService:      NewAuthService(),
+		cache:            redis.NewCache(),
+	}
+}
+
+// TOTPSetupResponse represents a TOTP secret waiting to be confirmed
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"`
+}
+
+// RecoveryCodesResponse represents newly issued recovery codes. They are only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFACodeRequest represents a request confirmed with a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFARequest represents the disable two-factor authentication request.
+// Password is required for accounts that have one.
+type DisableMFARequest struct {
+	Code     string `json:"code" binding:"required"`
+	Password string `json:"password"`
+}
+
+// MFAVerifyRequest represents the second step of a two-factor login
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfa_token" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// mfaChallenge is stored in Redis while a login waits for the second factor
+type mfaChallenge struct {
+	UserID     uuid.UUID `json:"user_id"`
+	DeviceName string    `json:"device_name,omitempty"`
+}
+
+// MFARequiredForRole reports whether users of a role must sign in with a second
+// factor before they can use their permissions
+func MFARequiredForRole(role string) bool {
+	return config.MFASetting.RequiredForAdmins && role == "admin"
+}
+
+// mfaChallengeExpiration is how long a login waits for the second factor
+func mfaChallengeExpiration() time.Duration {
+	if config.MFASetting.ChallengeExpireTime > 0 {
+		return time.Duration(config.MFASetting.ChallengeExpireTime) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// Setup generates a TOTP secret for a user. It is only enabled once a code
+// generated from it has been confirmed with Enable.
+func (s *MFAService) Setup(userID uuid.UUID) (*TOTPSetupResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	issuer := config.MFASetting.Issuer
+	if issuer == "" {
+		issuer = config.AppSetting.Name
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: mfaAccountName(user),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := s.cache.SetJSON(ctx, totpSetupKey(userID), key.Secret(), totpSetupExpiration); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetupResponse{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// Enable confirms the pending TOTP secret with a code and enables two-factor
+// authentication. The session it is called from counts as verified.
+func (s *MFAService) Enable(userID, sessionID uuid.UUID, req MFACodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	ctx := context.Background()
+	var secret string
+	if err := s.cache.GetJSON(ctx, totpSetupKey(userID), &secret); err != nil {
+		return nil, errors.New("two-factor setup has expired")
+	}
+
+	valid, err := s.checkTOTP(user.ID, secret, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TOTPSecret = encrypted
+	user.TOTPEnabledAt = &now
+	if err := s.userRepo.Update(user, nil); err != nil {
+		return nil, err
+	}
+	s.cache.Delete(ctx, totpSetupKey(userID))
+
+	codes, err := s.issueRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID != uuid.Nil {
+		if err := s.refreshTokenRepo.MarkFamilyMFAVerified(sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication after checking a code and the password
+func (s *MFAService) Disable(userID uuid.UUID, req DisableMFARequest) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if MFARequiredForRole(user.Role) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	// Wallet-only accounts have no password to check
+	if user.PasswordHash != "" && !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return errors.New("password is incorrect")
+	}
+
+	valid, err := s.verifyCode(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("invalid two-factor code")
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	if err := s.userRepo.Update(user, nil); err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteByUserID(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of a user after checking a code
+func (s *MFAService) RegenerateRecoveryCodes(userID uuid.UUID, req MFACodeRequest) (*RecoveryCodesResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	valid, err := s.verifyCode(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	return s.issueRecoveryCodes(user.ID)
+}
+
+// Verify completes a two-factor login with a TOTP or recovery code
+func (s *MFAService) Verify(req MFAVerifyRequest, client ClientInfo) (*TokenResponse, error) {
+	ctx := context.Background()
+	challengeHash := utils.HashToken(req.MFAToken)
+
+	var challenge mfaChallenge
+	if err := s.cache.PeekNonce(ctx, mfaChallengePurpose, challengeHash, &challenge); err != nil {
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	// Count the attempt before checking the code so that parallel guesses are limited too
+	attempts, err := s.cache.IncrementCounter(ctx, "mfa:attempts:"+challengeHash, mfaChallengeExpiration())
+	if err != nil {
+		return nil, err
+	}
+	if attempts > mfaMaxAttempts {
+		s.cache.ConsumeNonce(ctx, mfaChallengePurpose, challengeHash, &challenge)
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	valid, err := s.verifyCode(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		// Guessing codes across many challenges counts towards the account lockout
+		s.authService.loginFailed(user.EmailAddress(), user, "")
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	// The challenge is single use, even when two valid codes race
+	if err := s.cache.ConsumeNonce(ctx, mfaChallengePurpose, challengeHash, &challenge); err != nil {
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	if client.DeviceName == "" {
+		client.DeviceName = challenge.DeviceName
+	}
+	return s.authService.generateTokens(user, nil, client, true)
+}
+
+// verifyCode checks a TOTP code, or consumes a recovery code
+func (s *MFAService) verifyCode(user *models.User, code string) (bool, error) {
+	if user.TOTPEnabledAt == nil {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		secret, err := utils.DecryptSecret(user.TOTPSecret)
+		if err != nil {
+			return false, err
+		}
+		return s.checkTOTP(user.ID, secret, code)
+	}
+
+	return s.recoveryCodeRepo.Consume(user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+// checkTOTP validates a TOTP code. Each code is accepted only once per user.
+func (s *MFAService) checkTOTP(userID uuid.UUID, secret, code string) (bool, error) {
+	if !totp.Validate(code, secret) {
+		return false, nil
+	}
+
+	ctx := context.Background()
+	return s.cache.SetOnce(ctx, "mfa:used:"+userID.String()+":"+code, totpReplayWindow)
+}
+
+// issueRecoveryCodes replaces the recovery codes of a user. Only their hashes are stored.
+func (s *MFAService) issueRecoveryCodes(userID uuid.UUID) (*RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = utils.HashToken(code)
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// getUser gets a user by ID
+func (s *MFAService) getUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// normalizeRecoveryCode strips the formatting users may type around a recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// mfaAccountName is the account label shown in authenticator apps
+func mfaAccountName(user *models.User) string {
+	if email := user.EmailAddress(); email != "" {
+		return email
+	}
+	if wallet := user.PrimaryWallet(); wallet != nil {
+		return utils.ChecksumAddress(wallet.Address)
+	}
+	return user.ID.String()
+}
+
+// totpSetupKey is the cache key of a secret waiting to be confirmed
+func totpSetupKey(userID uuid.UUID) string {
+	return "mfa:setup:" + userID.String()
+}
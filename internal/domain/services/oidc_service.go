@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// oidcStatePurpose is the nonce purpose of pending provider logins
+const oidcStatePurpose = "oidc"
+
+// oidcProviders caches discovered providers so that their JWKS is fetched once
+var oidcProviders sync.Map
+
+// OIDCService signs users in with OpenID Connect providers using the
+// authorization code flow with PKCE
+type OIDCService struct {
+	userRepo     *repositories.UserRepository
+	identityRepo *repositories.UserIdentityRepository
+	passkeyRepo  *repositories.WebAuthnCredentialRepository
+	authService  *AuthService
+	cache        *redis.Cache
+}
+
+// NewOIDCService creates a new OpenID Connect service
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		userRepo:     repositories.NewUserRepository(),
+		identityRepo: repositories.NewUserIdentityRepository(),
+		passkeyRepo:  repositories.NewWebAuthnCredentialRepository(),
+		authService:  NewAuthService(),
+		cache:        redis.NewCache(),
+	}
+}
+
+// OIDCProviderResponse represents a configured identity provider
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCAuthorizeResponse represents the provider URL to send the browser to.
+// The client keeps the state to compare it with the one in the callback.
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OIDCCallbackRequest represents the code and state the provider redirected back with
+type OIDCCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// IdentityResponse represents a linked provider account
+type IdentityResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// oidcState is stored in Redis while the user is at the provider
+type oidcState struct {
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	UserID       uuid.UUID `json:"user_id,omitzero"`
+}
+
+// oidcClaims are the ID token claims used to create and link accounts
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// newIdentityResponse maps an identity model to an identity response
+func newIdentityResponse(identity *models.UserIdentity) IdentityResponse {
+	return IdentityResponse{
+		ID:          identity.ID,
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		LastLoginAt: identity.LastLoginAt,
+		CreatedAt:   identity.CreatedAt,
+	}
+}
+
+// Providers lists the configured identity providers
+func (s *OIDCService) Providers() []OIDCProviderResponse {
+	providers := []OIDCProviderResponse{}
+	for name, provider := range config.OIDCProviders {
+		displayName := provider.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		providers = append(providers, OIDCProviderResponse{Name: name, DisplayName: displayName})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// Authorize starts a login with a provider. When userID is set the provider
+// account is linked to that user instead.
+func (s *OIDCService) Authorize(providerName string, userID uuid.UUID) (*OIDCAuthorizeResponse, error) {
+	oauthConfig, _, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	stateValue, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	expiration := oidcStateExpiration()
+	ctx := context.Background()
+	state := oidcState{
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserID:       userID,
+	}
+	if err := s.cache.SetNonce(ctx, oidcStatePurpose, stateValue, state, expiration); err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthorizeResponse{
+		AuthorizationURL: oauthConfig.AuthCodeURL(stateValue, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:            stateValue,
+		ExpiresAt:        time.Now().Add(expiration),
+	}, nil
+}
+
+// Callback completes a provider login. Unknown provider accounts sign up a new
+// learner, or are linked to the user with the same email address when both the
+// provider and the user have verified it.
+func (s *OIDCService) Callback(providerName string, req OIDCCallbackRequest, client ClientInfo) (*TokenResponse, error) {
+	state, err := s.consumeState(providerName, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if state.UserID != uuid.Nil {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	subject, claims, err := s.exchange(providerName, state, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	identity, err := s.identityRepo.GetByProviderSubject(providerName, subject)
+	if err == nil {
+		// The identity of a deleted user stays linked until the user is purged
+		if identity.User.ID == uuid.Nil {
+			return nil, errors.New("this account has been deleted")
+		}
+		user = &identity.User
+		if err := s.identityRepo.UpdateLastLogin(identity.ID, claims.Email); err != nil {
+			return nil, err
+		}
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = s.signUp(providerName, subject, claims)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return s.authService.beginSession(user, client)
+}
+
+// Link completes linking a provider account to the signed in user
+func (s *OIDCService) Link(userID uuid.UUID, providerName string, req OIDCCallbackRequest) (*IdentityResponse, error) {
+	state, err := s.consumeState(providerName, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if state.UserID != userID {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	subject, claims, err := s.exchange(providerName, state, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.identityRepo.GetByProviderSubject(providerName, subject); err == nil {
+		if existing.UserID == userID {
+			return nil, errors.New("identity is already linked to your account")
+		}
+		return nil, errors.New("identity is already linked to another account")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	identity := &models.UserIdentity{
+		UserID:      userID,
+		Provider:    providerName,
+		Subject:     subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, errors.New("an identity of this provider is already linked to your account")
+	}
+
+	response := newIdentityResponse(identity)
+	return &response, nil
+}
+
+// ListIdentities lists the provider accounts linked to a user
+func (s *OIDCService) ListIdentities(userID uuid.UUID) ([]IdentityResponse, error) {
+	identities, err := s.identityRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []IdentityResponse{}
+	for i := range identities {
+		responses = append(responses, newIdentityResponse(&identities[i]))
+	}
+	return responses, nil
+}
+
+// DeleteIdentity unlinks a provider account, unless it is the last way to sign in
+func (s *OIDCService) DeleteIdentity(userID, identityID uuid.UUID) error {
+	identity, err := s.identityRepo.GetByID(identityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("identity not found")
+		}
+		return err
+	}
+	if identity.UserID != userID {
+		return errors.New("identity not found")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	count, err := s.identityRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	passkeys, err := s.passkeyRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" && len(user.Wallets)+int(count)+int(passkeys) <= 1 {
+		return errors.New("cannot remove the only sign-in method of this account")
+	}
+
+	return s.identityRepo.Delete(identity.ID)
+}
+
+// signUp creates or links the account of a provider subject seen for the first time
+func (s *OIDCService) signUp(providerName, subject string, claims *oidcClaims) (*models.User, error) {
+	now := time.Now()
+	identity := &models.UserIdentity{
+		Provider:    providerName,
+		Subject:     subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+
+	if claims.Email != "" {
+		existing, err := s.userRepo.GetByEmail(claims.Email)
+		if err == nil {
+			// Only link automatically when both sides have proven the address
+			if !claims.EmailVerified || existing.EmailVerifiedAt == nil {
+				return nil, errors.New("an account with this email already exists, sign in and link the provider from your profile")
+			}
+			identity.UserID = existing.ID
+			if err := s.identityRepo.Create(identity); err != nil {
+				return nil, err
+			}
+			log.Printf("Linked %s identity to existing user %s by verified email", providerName, existing.ID)
+			return existing, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	user := &models.User{
+		FullName:       claims.Name,
+		Role:           DefaultRole,
+		ProfilePicture: claims.Picture,
+	}
+	if user.FullName == "" {
+		user.FullName = claims.Email
+	}
+	if claims.Email != "" {
+		email := claims.Email
+		user.Email = &email
+		if claims.EmailVerified {
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	if err := s.identityRepo.CreateUserWithIdentity(user, identity); err != nil {
+		// Another request may have signed up the same subject concurrently
+		if existing, getErr := s.identityRepo.GetByProviderSubject(providerName, subject); getErr == nil {
+			return &existing.User, nil
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// consumeState loads and deletes the state of a pending provider login
+func (s *OIDCService) consumeState(providerName, stateValue string) (*oidcState, error) {
+	ctx := context.Background()
+	var state oidcState
+	if err := s.cache.ConsumeNonce(ctx, oidcStatePurpose, stateValue, &state); err != nil {
+		return nil, errors.New("invalid or expired state")
+	}
+	if state.Provider != providerName {
+		return nil, errors.New("invalid or expired state")
+	}
+	return &state, nil
+}
+
+// exchange redeems an authorization code and validates the ID token against the
+// provider's JWKS, returning the subject and claims
+func (s *OIDCService) exchange(providerName string, state *oidcState, code string) (string, *oidcClaims, error) {
+	oauthConfig, provider, err := s.provider(providerName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		log.Printf("Failed to exchange %s authorization code: %v", providerName, err)
+		return "", nil, errors.New("failed to sign in with the identity provider")
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", nil, errors.New("identity provider did not return an ID token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: oauthConfig.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("Failed to verify %s ID token: %v", providerName, err)
+		return "", nil, errors.New("invalid ID token")
+	}
+	if idToken.Nonce != state.Nonce {
+		return "", nil, errors.New("invalid ID token")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return "", nil, err
+	}
+
+	return idToken.Subject, &claims, nil
+}
+
+// provider returns the OAuth2 configuration and discovered provider of a
+// configured provider. Discovery happens on first use and is cached.
+func (s *OIDCService) provider(name string) (*oauth2.Config, *oidc.Provider, error) {
+	settings, ok := config.OIDCProviders[name]
+	if !ok {
+		return nil, nil, errors.New("unknown identity provider")
+	}
+
+	var provider *oidc.Provider
+	if cached, ok := oidcProviders.Load(name); ok {
+		provider = cached.(*oidc.Provider)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		discovered, err := oidc.NewProvider(ctx, settings.IssuerURL)
+		if err != nil {
+			log.Printf("Failed to discover identity provider %s: %v", name, err)
+			return nil, nil, errors.New("identity provider is unavailable")
+		}
+		oidcProviders.Store(name, discovered)
+		provider = discovered
+	}
+
+	scopes := settings.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		RedirectURL:  settings.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}, provider, nil
+}
+
+// oidcStateExpiration is how long a user may take to log in at the provider
+func oidcStateExpiration() time.Duration {
+	if config.OIDCSetting.StateExpireTime > 0 {
+		return time.Duration(config.OIDCSetting.StateExpireTime) * time.Second
+	}
+	return 10 * time.Minute
+}
+
+// randomHex returns n random bytes as a hex string
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// passkeyRegistrationPurpose is the nonce purpose of pending passkey registrations, one per user
+	passkeyRegistrationPurpose = "passkey_registration"
+	// passkeyLoginPurpose is the nonce purpose of pending passkey logins
+	passkeyLoginPurpose = "passkey_login"
+)
+
+// PasskeyService registers WebAuthn passkeys and signs users in with them. Logins
+// use discoverable credentials, so the user does not enter an email address.
+type PasskeyService struct {
+	credentialRepo *repositories.WebAuthnCredentialRepository
+	userRepo       *repositories.UserRepository
+	identityRepo   *repositories.UserIdentityRepository
+	authService    *AuthService
+	cache          *redis.Cache
+}
+
+// NewPasskeyService creates a new passkey service
+func NewPasskeyService() *PasskeyService {
+	return &PasskeyService{
+		credentialRepo: repositories.NewWebAuthnCredentialRepository(),
+		userRepo:       repositories.NewUserRepository(),
+		identityRepo:   repositories.NewUserIdentityRepository(),
+		authService:    NewAuthService(),
+		cache:          redis.NewCache(),
+	}
+}
+
+// PasskeyResponse represents a registered passkey
+type PasskeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports,omitempty"`
+	Synced     bool       `json:"synced"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PasskeyRegistrationResponse carries the options for navigator.credentials.create()
+type PasskeyRegistrationResponse struct {
+	Options   *protocol.CredentialCreation `json:"options"`
+	ExpiresAt time.Time                    `json:"expires_at"`
+}
+
+// RegisterPasskeyRequest represents the register passkey request. Credential is
+// the PublicKeyCredential returned by navigator.credentials.create() in its JSON form.
+type RegisterPasskeyRequest struct {
+	Name       string          `json:"name" binding:"max=100"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+// RenamePasskeyRequest represents the rename passkey request
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// PasskeyLoginResponse carries the options for navigator.credentials.get() and
+// the challenge ID to send back with the assertion
+type PasskeyLoginResponse struct {
+	ChallengeID string                        `json:"challenge_id"`
+	Options     *protocol.CredentialAssertion `json:"options"`
+	ExpiresAt   time.Time                     `json:"expires_at"`
+}
+
+// PasskeyLoginRequest represents the passkey login request. Credential is the
+// PublicKeyCredential returned by navigator.credentials.get() in its JSON form.
+type PasskeyLoginRequest struct {
+	ChallengeID string          `json:"challenge_id" binding:"required"`
+	Credential  json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+	DeviceName  string          `json:"device_name"`
+}
+
+// passkeyUser adapts a user and its passkeys to the webauthn.User interface
+type passkeyUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+// WebAuthnID returns the user handle, which is the user ID
+func (u *passkeyUser) WebAuthnID() []byte {
+	id := u.user.ID
+	return id[:]
+}
+
+// WebAuthnName returns the account name shown by authenticators
+func (u *passkeyUser) WebAuthnName() string {
+	if email := u.user.EmailAddress(); email != "" {
+		return email
+	}
+	if wallet := u.user.PrimaryWallet(); wallet != nil {
+		return utils.ChecksumAddress(wallet.Address)
+	}
+	return u.user.FullName
+}
+
+// WebAuthnDisplayName returns the name shown by authenticators
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.FullName != "" {
+		return u.user.FullName
+	}
+	return u.WebAuthnName()
+}
+
+// WebAuthnCredentials returns the stored passkeys as WebAuthn credential records
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		credential := webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    true,
+				UserVerified:   c.UserVerified,
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		}
+		for _, transport := range splitTransports(c.Transports) {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+// newPasskeyResponse maps a passkey model to a passkey response
+func newPasskeyResponse(credential *models.WebAuthnCredential) PasskeyResponse {
+	return PasskeyResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Transports: splitTransports(credential.Transports),
+		Synced:     credential.BackupState,
+		LastUsedAt: credential.LastUsedAt,
+		CreatedAt:  credential.CreatedAt,
+	}
+}
+
+// List lists the passkeys of a user
+func (s *PasskeyService) List(userID uuid.UUID) ([]PasskeyResponse, error) {
+	credentials, err := s.credentialRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []PasskeyResponse{}
+	for i := range credentials {
+		responses = append(responses, newPasskeyResponse(&credentials[i]))
+	}
+	return responses, nil
+}
+
+// BeginRegistration starts registering a passkey for a user. Passkeys the user
+// already has are excluded so that an authenticator is not registered twice.
+func (s *PasskeyService) BeginRegistration(userID uuid.UUID) (*PasskeyRegistrationResponse, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.passkeyUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := rp.BeginRegistration(owner,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(owner.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := s.cache.SetNonce(ctx, passkeyRegistrationPurpose, userID.String(), session, passkeyChallengeExpiration()); err != nil {
+		return nil, err
+	}
+
+	return &PasskeyRegistrationResponse{
+		Options:   creation,
+		ExpiresAt: time.Now().Add(passkeyChallengeExpiration()),
+	}, nil
+}
+
+// FinishRegistration verifies the attestation of a new passkey and stores it
+func (s *PasskeyService) FinishRegistration(userID uuid.UUID, req RegisterPasskeyRequest) (*PasskeyResponse, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var session webauthn.SessionData
+	if err := s.cache.ConsumeNonce(ctx, passkeyRegistrationPurpose, userID.String(), &session); err != nil {
+		return nil, errors.New("invalid or expired passkey challenge")
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errors.New("invalid passkey response")
+	}
+
+	owner, err := s.passkeyUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := rp.CreateCredential(owner, session, parsed)
+	if err != nil {
+		log.Printf("Passkey registration of user %s failed: %v", userID, err)
+		return nil, errors.New("passkey verification failed")
+	}
+
+	if _, err := s.credentialRepo.GetByCredentialID(credential.ID); err == nil {
+		return nil, errors.New("passkey is already registered")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	passkey := &models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      strings.Join(transports, ","),
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	if err := s.credentialRepo.Create(passkey); err != nil {
+		return nil, err
+	}
+
+	response := newPasskeyResponse(passkey)
+	return &response, nil
+}
+
+// Rename renames a passkey of a user
+func (s *PasskeyService) Rename(userID, passkeyID uuid.UUID, req RenamePasskeyRequest) (*PasskeyResponse, error) {
+	passkey, err := s.ownPasskey(userID, passkeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey.Name = strings.TrimSpace(req.Name)
+	if passkey.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := s.credentialRepo.Rename(passkey.ID, passkey.Name); err != nil {
+		return nil, err
+	}
+
+	response := newPasskeyResponse(passkey)
+	return &response, nil
+}
+
+// Delete removes a passkey, unless it is the last way to sign in
+func (s *PasskeyService) Delete(userID, passkeyID uuid.UUID) error {
+	passkey, err := s.ownPasskey(userID, passkeyID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	identities, err := s.identityRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	passkeys, err := s.credentialRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" && len(user.Wallets)+int(identities)+int(passkeys) <= 1 {
+		return errors.New("cannot remove the only sign-in method of this account")
+	}
+
+	return s.credentialRepo.Delete(passkey.ID)
+}
+
+// BeginLogin starts a passkey login. Any passkey registered for this site may answer it.
+func (s *PasskeyService) BeginLogin() (*PasskeyLoginResponse, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := rp.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateSignedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the hash of the challenge ID is stored
+	ctx := context.Background()
+	if err := s.cache.SetNonce(ctx, passkeyLoginPurpose, utils.HashToken(token), session, passkeyChallengeExpiration()); err != nil {
+		return nil, err
+	}
+
+	return &PasskeyLoginResponse{
+		ChallengeID: token,
+		Options:     assertion,
+		ExpiresAt:   time.Now().Add(passkeyChallengeExpiration()),
+	}, nil
+}
+
+// FinishLogin verifies a passkey assertion and logs its owner in. A passkey
+// unlocked with a PIN or biometrics counts as two factors; otherwise users with
+// two-factor authentication still have to enter a code.
+func (s *PasskeyService) FinishLogin(req PasskeyLoginRequest, client ClientInfo) (*TokenResponse, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each challenge can be answered once
+	ctx := context.Background()
+	var session webauthn.SessionData
+	if err := s.cache.ConsumeNonce(ctx, passkeyLoginPurpose, utils.HashToken(req.ChallengeID), &session); err != nil {
+		return nil, errors.New("invalid or expired passkey challenge")
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return nil, errors.New("invalid passkey response")
+	}
+
+	var passkey *models.WebAuthnCredential
+	var owner *passkeyUser
+	lookup := func(rawID, userHandle []byte) (webauthn.User, error) {
+		credential, err := s.credentialRepo.GetByCredentialID(rawID)
+		if err != nil {
+			return nil, err
+		}
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil || userID != credential.UserID {
+			return nil, errors.New("user handle does not match the passkey")
+		}
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		passkey = credential
+		owner = &passkeyUser{user: user, credentials: []models.WebAuthnCredential{*credential}}
+		return owner, nil
+	}
+
+	_, credential, err := rp.ValidatePasskeyLogin(lookup, session, parsed)
+	if err != nil {
+		log.Printf("Passkey login failed: %v", err)
+		return nil, errors.New("passkey verification failed")
+	}
+
+	// A signature counter that went backwards means the key may have been copied
+	if credential.Authenticator.CloneWarning {
+		log.Printf("SECURITY: passkey %s of user %s reported a stale signature counter", passkey.ID, passkey.UserID)
+		return nil, errors.New("passkey verification failed")
+	}
+
+	if err := s.credentialRepo.UpdateAfterLogin(passkey.ID, credential.Authenticator.SignCount, credential.Flags.BackupState); err != nil {
+		return nil, err
+	}
+
+	if credential.Flags.UserVerified {
+		return s.authService.generateTokens(owner.user, nil, client, true)
+	}
+	return s.authService.beginSession(owner.user, client)
+}
+
+// passkeyUser loads a user with their passkeys
+func (s *PasskeyService) passkeyUser(userID uuid.UUID) (*passkeyUser, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	credentials, err := s.credentialRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// ownPasskey loads a passkey of a user
+func (s *PasskeyService) ownPasskey(userID, passkeyID uuid.UUID) (*models.WebAuthnCredential, error) {
+	passkey, err := s.credentialRepo.GetByID(passkeyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("passkey not found")
+		}
+		return nil, err
+	}
+	if passkey.UserID != userID {
+		return nil, errors.New("passkey not found")
+	}
+	return passkey, nil
+}
+
+// relyingParty builds the WebAuthn relying party from the [webauthn] settings.
+// The relying party ID and origin default to the frontend URL.
+var relyingParty = sync.OnceValues(func() (*webauthn.WebAuthn, error) {
+	settings := config.WebAuthnSetting
+
+	rpID := settings.RPID
+	origins := settings.RPOrigins
+	if frontend, err := url.Parse(config.AppSetting.FrontendURL); err == nil && frontend.Host != "" {
+		if rpID == "" {
+			rpID = frontend.Hostname()
+		}
+		if len(origins) == 0 {
+			origins = []string{frontend.Scheme + "://" + frontend.Host}
+		}
+	}
+
+	displayName := settings.RPDisplayName
+	if displayName == "" {
+		displayName = config.AppSetting.Name
+	}
+
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    passkeyChallengeExpiration(),
+		TimeoutUVD: passkeyChallengeExpiration(),
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: displayName,
+		RPOrigins:     origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+})
+
+// passkeyChallengeExpiration is how long a passkey registration or login may take
+func passkeyChallengeExpiration() time.Duration {
+	return secondsOr(config.WebAuthnSetting.ChallengeExpireTime, 5*time.Minute)
+}
+
+// splitTransports splits the stored comma separated authenticator transports
+func splitTransports(transports string) []string {
+	if transports == "" {
+		return nil
+	}
+	return strings.Split(transports, ",")
+}
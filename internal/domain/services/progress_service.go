@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultProgressCompleteThreshold = 90
+	defaultProgressFlushInterval     = 30 * time.Second
+	progressCacheExpiration          = 24 * time.Hour
+)
+
+type ProgressService struct {
+	progressRepo   *repositories.ProgressRepository
+	lessonRepo     *repositories.LessonRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	cache          *redis.Cache
+}
+
+// NewProgressService creates a new progress service
+func NewProgressService() *ProgressService {
+	return &ProgressService{
+		progressRepo:   repositories.NewProgressRepository(),
+		lessonRepo:     repositories.NewLessonRepository(),
+		enrollmentRepo: repositories.NewEnrollmentRepository(),
+		cache:          redis.NewCache(),
+	}
+}
+
+// ProgressResponse represents the lesson progress response
+type ProgressResponse struct {
+	LessonID        uuid.UUID `json:"lesson_id"`
+	PositionSeconds int       `json:"position_seconds"`
+	Completed       bool      `json:"completed"`
+	LastWatchedAt   time.Time `json:"last_watched_at"`
+}
+
+// UpdateProgressRequest represents a playback position heartbeat
+type UpdateProgressRequest struct {
+	PositionSeconds int `json:"position_seconds" binding:"min=0"`
+}
+
+// CourseProgressResponse represents a user's progress summary for a course
+type CourseProgressResponse struct {
+	CourseID         uuid.UUID     `json:"course_id"`
+	CompletedLessons int           `json:"completed_lessons"`
+	TotalLessons     int           `json:"total_lessons"`
+	Percentage       float64       `json:"percentage"`
+	WatchedSeconds   int           `json:"watched_seconds"`
+	NextLesson       *ResumeLesson `json:"next_lesson,omitempty"`
+}
+
+// ResumeLesson represents the lesson to resume and the position to resume at
+type ResumeLesson struct {
+	LessonBrief
+	PositionSeconds int `json:"position_seconds"`
+}
+
+// lessonProgressEntry is the buffered progress of a single lesson
+type lessonProgressEntry struct {
+	PositionSeconds int       `json:"position_seconds"`
+	Completed       bool      `json:"completed"`
+	LastWatchedAt   time.Time `json:"last_watched_at"`
+}
+
+// GetProgress gets a user's progress for a lesson
+func (s *ProgressService) GetProgress(userID, lessonID uuid.UUID) (*ProgressResponse, error) {
+	lesson, err := s.getLessonForUser(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.loadEntry(userID, lesson)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapProgressEntryToResponse(lessonID, entry), nil
+}
+
+// UpdateProgress records a playback position heartbeat for a lesson.
+// Heartbeats are buffered in Redis and written to the database by the flush
+// job once per flush interval, or immediately when the lesson becomes completed.
+func (s *ProgressService) UpdateProgress(userID, lessonID uuid.UUID, req UpdateProgressRequest) (*ProgressResponse, error) {
+	lesson, err := s.getLessonForUser(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.loadEntry(userID, lesson)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry.PositionSeconds = req.PositionSeconds
+	entry.LastWatchedAt = now
+
+	// Auto-complete once enough of the lesson has been watched
+	justCompleted := false
+	if !entry.Completed && reachedCompletion(lesson, req.PositionSeconds) {
+		entry.Completed = true
+		justCompleted = true
+	}
+
+	if justCompleted {
+		if err := s.flushEntry(userID, lessonID, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.saveEntry(userID, lesson, entry, justCompleted); err != nil {
+		return nil, err
+	}
+
+	return mapProgressEntryToResponse(lessonID, entry), nil
+}
+
+// Complete marks a lesson as completed for a user
+func (s *ProgressService) Complete(userID, lessonID uuid.UUID) (*ProgressResponse, error) {
+	lesson, err := s.getLessonForUser(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.loadEntry(userID, lesson)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Completed = true
+	entry.LastWatchedAt = time.Now()
+
+	if err := s.flushEntry(userID, lessonID, entry); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveEntry(userID, lesson, entry, true); err != nil {
+		return nil, err
+	}
+
+	return mapProgressEntryToResponse(lessonID, entry), nil
+}
+
+// GetCourseProgress gets a user's progress summary for a course
+func (s *ProgressService) GetCourseProgress(userID, courseID uuid.UUID) (*CourseProgressResponse, error) {
+	enrolled, err := s.enrollmentRepo.IsEnrolled(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, errors.New("not enrolled in this course")
+	}
+
+	lessons, err := s.lessonRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.summarizeCourse(userID, courseID, lessons)
+}
+
+// summarizeCourse builds the progress summary of a user for the given course lessons
+func (s *ProgressService) summarizeCourse(userID, courseID uuid.UUID, lessons []models.Lesson) (*CourseProgressResponse, error) {
+	stored, err := s.progressRepo.GetByCourseAndUserID(courseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start from the database and overlay buffered heartbeats that have not been flushed yet
+	entries := make(map[uuid.UUID]*lessonProgressEntry)
+	for _, p := range stored {
+		entries[p.LessonID] = &lessonProgressEntry{
+			PositionSeconds: p.PositionSeconds,
+			Completed:       p.Completed,
+			LastWatchedAt:   p.LastWatchedAt,
+		}
+	}
+	buffered, _ := s.cache.GetUserProgress(context.Background(), userID.String(), courseID.String())
+	for lessonID, data := range buffered {
+		id, err := uuid.Parse(lessonID)
+		if err != nil {
+			continue
+		}
+		var entry lessonProgressEntry
+		if err := json.Unmarshal([]byte(data), &entry); err == nil {
+			entries[id] = &entry
+		}
+	}
+
+	response := &CourseProgressResponse{
+		CourseID:     courseID,
+		TotalLessons: len(lessons),
+	}
+
+	// Find the most recently watched lesson while summing up
+	lastWatched := -1
+	var lastWatchedAt time.Time
+	for i, lesson := range lessons {
+		entry, ok := entries[lesson.ID]
+		if !ok {
+			continue
+		}
+
+		durationSeconds := lesson.Duration * 60
+		switch {
+		case entry.Completed && durationSeconds > 0:
+			response.WatchedSeconds += durationSeconds
+		case durationSeconds > 0 && entry.PositionSeconds > durationSeconds:
+			response.WatchedSeconds += durationSeconds
+		default:
+			response.WatchedSeconds += entry.PositionSeconds
+		}
+
+		if entry.Completed {
+			response.CompletedLessons++
+		}
+
+		if entry.LastWatchedAt.After(lastWatchedAt) {
+			lastWatched = i
+			lastWatchedAt = entry.LastWatchedAt
+		}
+	}
+
+	if response.TotalLessons > 0 {
+		response.Percentage = float64(response.CompletedLessons) * 100 / float64(response.TotalLessons)
+	}
+
+	response.NextLesson = nextLesson(lessons, entries, lastWatched)
+
+	return response, nil
+}
+
+// nextLesson picks the lesson to resume. An unfinished last-watched lesson is resumed at its
+// saved position, otherwise the first unfinished lesson after it (or from the start) is used.
+func nextLesson(lessons []models.Lesson, entries map[uuid.UUID]*lessonProgressEntry, lastWatched int) *ResumeLesson {
+	if len(lessons) == 0 {
+		return nil
+	}
+
+	start := 0
+	if lastWatched >= 0 {
+		start = lastWatched
+	}
+
+	for i := 0; i < len(lessons); i++ {
+		lesson := lessons[(start+i)%len(lessons)]
+		entry, ok := entries[lesson.ID]
+		if ok && entry.Completed {
+			continue
+		}
+
+		resume := &ResumeLesson{
+			LessonBrief: LessonBrief{
+				ID:          lesson.ID,
+				Title:       lesson.Title,
+				Description: lesson.Description,
+				Duration:    lesson.Duration,
+				OrderNumber: lesson.OrderNumber,
+			},
+		}
+		if ok {
+			resume.PositionSeconds = entry.PositionSeconds
+		}
+		return resume
+	}
+
+	// Every lesson is completed
+	return nil
+}
+
+// getLessonForUser gets a lesson and checks that the user is enrolled in its course
+func (s *ProgressService) getLessonForUser(userID, lessonID uuid.UUID) (*models.Lesson, error) {
+	lesson, err := s.lessonRepo.GetByID(lessonID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson not found")
+		}
+		return nil, err
+	}
+
+	enrolled, err := s.enrollmentRepo.IsEnrolled(userID, lesson.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, errors.New("not enrolled in this course")
+	}
+
+	return lesson, nil
+}
+
+// loadEntry loads the buffered progress for a lesson, falling back to the database
+func (s *ProgressService) loadEntry(userID uuid.UUID, lesson *models.Lesson) (*lessonProgressEntry, error) {
+	ctx := context.Background()
+
+	entry := &lessonProgressEntry{}
+	found, err := s.cache.GetLessonProgress(ctx, userID.String(), lesson.CourseID.String(), lesson.ID.String(), entry)
+	if err == nil && found {
+		return entry, nil
+	}
+
+	stored, err := s.progressRepo.GetByUserAndLessonID(userID, lesson.ID)
+	if err == nil {
+		entry.PositionSeconds = stored.PositionSeconds
+		entry.Completed = stored.Completed
+		entry.LastWatchedAt = stored.LastWatchedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// flushEntry writes the buffered progress for a lesson to the database
+func (s *ProgressService) flushEntry(userID, lessonID uuid.UUID, entry *lessonProgressEntry) error {
+	return s.progressRepo.Upsert(&models.Progress{
+		UserID:          userID,
+		LessonID:        lessonID,
+		PositionSeconds: entry.PositionSeconds,
+		Completed:       entry.Completed,
+		LastWatchedAt:   entry.LastWatchedAt,
+	})
+}
+
+// saveEntry buffers the progress for a lesson in Redis. Progress that has not
+// just been written to the database is marked for the flush job, and written
+// right away when it cannot be buffered.
+func (s *ProgressService) saveEntry(userID uuid.UUID, lesson *models.Lesson, entry *lessonProgressEntry, flushed bool) error {
+	ctx := context.Background()
+	user, course, lessonID := userID.String(), lesson.CourseID.String(), lesson.ID.String()
+
+	err := s.cache.SetLessonProgress(ctx, user, course, lessonID, entry, progressCacheExpiration)
+	if err == nil && !flushed {
+		err = s.cache.MarkProgressDirty(ctx, user, course, lessonID, time.Now())
+	}
+	if err != nil && !flushed {
+		return s.flushEntry(userID, lesson.ID, entry)
+	}
+	return nil
+}
+
+// RunFlushJob writes the buffered progress to the database every flush
+// interval until the context is cancelled. Call Flush after stopping the
+// server to write the heartbeats received since the last run.
+func (s *ProgressService) RunFlushJob(ctx context.Context) {
+	ticker := time.NewTicker(progressFlushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+// Flush writes the buffered progress marked by heartbeats to the database.
+// Every instance may flush; writing the same progress twice does no harm.
+func (s *ProgressService) Flush() {
+	ctx := context.Background()
+	dirty, err := s.cache.GetDirtyProgress(ctx)
+	if err != nil {
+		log.Printf("Failed to list buffered progress: %v", err)
+		return
+	}
+
+	for _, p := range dirty {
+		if err := s.flushDirty(ctx, p); err != nil {
+			log.Printf("Failed to flush progress of user %s for lesson %s: %v", p.UserID, p.LessonID, err)
+		}
+	}
+}
+
+// flushDirty writes one lesson's buffered progress to the database and clears
+// its mark. Progress that is no longer buffered, e.g. after unenrolling, is
+// dropped.
+func (s *ProgressService) flushDirty(ctx context.Context, p redis.DirtyProgress) error {
+	userID, err := uuid.Parse(p.UserID)
+	if err != nil {
+		return err
+	}
+	lessonID, err := uuid.Parse(p.LessonID)
+	if err != nil {
+		return err
+	}
+
+	var entry lessonProgressEntry
+	found, err := s.cache.GetLessonProgress(ctx, p.UserID, p.CourseID, p.LessonID, &entry)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := s.flushEntry(userID, lessonID, &entry); err != nil {
+			return err
+		}
+	}
+
+	return s.cache.ClearDirtyProgress(ctx, p)
+}
+
+// reachedCompletion reports whether the position passes the completion threshold of the lesson
+func reachedCompletion(lesson *models.Lesson, positionSeconds int) bool {
+	if lesson.Duration <= 0 {
+		return false
+	}
+
+	threshold := config.AppSetting.ProgressCompleteThreshold
+	if threshold <= 0 || threshold > 100 {
+		threshold = defaultProgressCompleteThreshold
+	}
+
+	// Lesson duration is stored in minutes
+	return positionSeconds*100 >= lesson.Duration*60*threshold
+}
+
+// progressFlushInterval returns the time between runs of the flush job
+func progressFlushInterval() time.Duration {
+	if config.AppSetting.ProgressFlushInterval <= 0 {
+		return defaultProgressFlushInterval
+	}
+	return time.Duration(config.AppSetting.ProgressFlushInterval) * time.Second
+}
+
+// mapProgressEntryToResponse maps a buffered progress entry to a progress response
+func mapProgressEntryToResponse(lessonID uuid.UUID, entry *lessonProgressEntry) *ProgressResponse {
+	return &ProgressResponse{
+		LessonID:        lessonID,
+		PositionSeconds: entry.PositionSeconds,
+		Completed:       entry.Completed,
+		LastWatchedAt:   entry.LastWatchedAt,
+	}
+}
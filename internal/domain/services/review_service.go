@@ -0,0 +1,307 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewService manages course reviews. The review repository keeps the rating
+// average and count of each course up to date with its visible reviews.
+type ReviewService struct {
+	reviewRepo     *repositories.CourseReviewRepository
+	courseRepo     *repositories.CourseRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	cache          *redis.Cache
+}
+
+// NewReviewService creates a new review service
+func NewReviewService() *ReviewService {
+	return &ReviewService{
+		reviewRepo:     repositories.NewCourseReviewRepository(),
+		courseRepo:     repositories.NewCourseRepository(),
+		enrollmentRepo: repositories.NewEnrollmentRepository(),
+		cache:          redis.NewCache(),
+	}
+}
+
+// ReviewResponse represents a course review
+type ReviewResponse struct {
+	ID           uuid.UUID        `json:"id"`
+	CourseID     uuid.UUID        `json:"course_id"`
+	Author       ReviewerResponse `json:"author"`
+	Rating       int              `json:"rating"`
+	Comment      string           `json:"comment,omitempty"`
+	Reply        string           `json:"reply,omitempty"`
+	RepliedAt    *time.Time       `json:"replied_at,omitempty"`
+	Hidden       bool             `json:"hidden,omitempty"`
+	HiddenReason string           `json:"hidden_reason,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// ReviewerResponse is the public profile of a review author
+type ReviewerResponse struct {
+	ID             uuid.UUID `json:"id"`
+	FullName       string    `json:"full_name"`
+	ProfilePicture string    `json:"profile_picture,omitempty"`
+}
+
+// CreateReviewRequest represents the create review request
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=5000"`
+}
+
+// UpdateReviewRequest represents the update review request. Omitted fields are left unchanged.
+type UpdateReviewRequest struct {
+	Rating  *int    `json:"rating" binding:"omitempty,min=1,max=5"`
+	Comment *string `json:"comment" binding:"omitempty,max=5000"`
+}
+
+// ReplyReviewRequest represents the instructor reply request
+type ReplyReviewRequest struct {
+	Reply string `json:"reply" binding:"required,max=5000"`
+}
+
+// ModerateReviewRequest represents the moderation request
+type ModerateReviewRequest struct {
+	Hidden bool   `json:"hidden"`
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// newReviewResponse maps a review model to a review response
+func newReviewResponse(review *models.CourseReview) ReviewResponse {
+	return ReviewResponse{
+		ID:       review.ID,
+		CourseID: review.CourseID,
+		Author: ReviewerResponse{
+			ID:             review.UserID,
+			FullName:       review.User.FullName,
+			ProfilePicture: review.User.ProfilePicture,
+		},
+		Rating:       review.Rating,
+		Comment:      review.Comment,
+		Reply:        review.Reply,
+		RepliedAt:    review.RepliedAt,
+		Hidden:       review.HiddenAt != nil,
+		HiddenReason: review.HiddenReason,
+		CreatedAt:    review.CreatedAt,
+		UpdatedAt:    review.UpdatedAt,
+	}
+}
+
+// List lists the reviews of a course, newest first. Hidden reviews are only
+// listed for admins.
+func (s *ReviewService) List(courseID uuid.UUID, role string, page, pageSize int) ([]ReviewResponse, int64, error) {
+	if _, err := s.courseRepo.GetByID(courseID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.New("course not found")
+		}
+		return nil, 0, err
+	}
+
+	reviews, count, err := s.reviewRepo.GetByCourseID(courseID, role == "admin", page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := []ReviewResponse{}
+	for i := range reviews {
+		responses = append(responses, newReviewResponse(&reviews[i]))
+	}
+	return responses, count, nil
+}
+
+// Create adds the review of an enrolled learner to a course
+func (s *ReviewService) Create(courseID, userID uuid.UUID, req CreateReviewRequest) (*ReviewResponse, error) {
+	if _, err := s.courseRepo.GetByID(courseID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("course not found")
+		}
+		return nil, err
+	}
+
+	enrolled, err := s.enrollmentRepo.IsEnrolled(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, errors.New("you must be enrolled in this course to review it")
+	}
+
+	reviewed, err := s.reviewRepo.ExistsByUserAndCourseID(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewed {
+		return nil, errors.New("you have already reviewed this course")
+	}
+
+	review := &models.CourseReview{
+		CourseID: courseID,
+		UserID:   userID,
+		Rating:   req.Rating,
+		Comment:  strings.TrimSpace(req.Comment),
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		return nil, err
+	}
+	s.invalidateCourse(courseID)
+
+	return s.reload(review.ID)
+}
+
+// Update edits the rating or comment of a user's own review
+func (s *ReviewService) Update(reviewID, userID uuid.UUID, req UpdateReviewRequest) (*ReviewResponse, error) {
+	review, err := s.ownReview(reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Rating != nil {
+		review.Rating = *req.Rating
+	}
+	if req.Comment != nil {
+		review.Comment = strings.TrimSpace(*req.Comment)
+	}
+
+	if err := s.reviewRepo.Update(review, nil); err != nil {
+		return nil, err
+	}
+	s.invalidateCourse(review.CourseID)
+
+	response := newReviewResponse(review)
+	return &response, nil
+}
+
+// Delete deletes a user's own review
+func (s *ReviewService) Delete(reviewID, userID uuid.UUID) error {
+	review, err := s.ownReview(reviewID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.reviewRepo.Delete(review); err != nil {
+		return err
+	}
+	s.invalidateCourse(review.CourseID)
+
+	return nil
+}
+
+// Reply sets the public reply to a review. Only the course instructor and
+// admins may reply; a new reply replaces the previous one.
+func (s *ReviewService) Reply(reviewID, userID uuid.UUID, role string, req ReplyReviewRequest) (*ReviewResponse, error) {
+	review, err := s.review(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := checkCourseInstructor(s.courseRepo, review.CourseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	reply := strings.TrimSpace(req.Reply)
+	if reply == "" {
+		return nil, errors.New("reply is required")
+	}
+
+	now := time.Now()
+	review.Reply = reply
+	review.RepliedBy = &userID
+	review.RepliedAt = &now
+
+	if err := s.reviewRepo.Update(review, nil); err != nil {
+		return nil, err
+	}
+
+	response := newReviewResponse(review)
+	return &response, nil
+}
+
+// Moderate hides an abusive review or shows it again. Hidden reviews do not
+// count towards the course rating.
+func (s *ReviewService) Moderate(reviewID, moderatorID uuid.UUID, req ModerateReviewRequest, audit *AuditRecord) (*ReviewResponse, error) {
+	review, err := s.review(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	before := newModerationState(review)
+
+	if req.Hidden {
+		now := time.Now()
+		review.HiddenAt = &now
+		review.HiddenBy = &moderatorID
+		review.HiddenReason = strings.TrimSpace(req.Reason)
+	} else {
+		review.HiddenAt = nil
+		review.HiddenBy = nil
+		review.HiddenReason = ""
+	}
+
+	event := audit.Change(models.AuditActionReviewModerate, models.AuditTargetReview, review.ID, before, newModerationState(review))
+	if err := s.reviewRepo.Update(review, event); err != nil {
+		return nil, err
+	}
+	s.invalidateCourse(review.CourseID)
+
+	response := newReviewResponse(review)
+	return &response, nil
+}
+
+// moderationState is the moderation of a review recorded in the audit log
+type moderationState struct {
+	Hidden bool   `json:"hidden"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// newModerationState takes the moderation state of a review for the audit log
+func newModerationState(review *models.CourseReview) moderationState {
+	return moderationState{Hidden: review.HiddenAt != nil, Reason: review.HiddenReason}
+}
+
+// review loads a review
+func (s *ReviewService) review(reviewID uuid.UUID) (*models.CourseReview, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+// ownReview loads a review written by the user
+func (s *ReviewService) ownReview(reviewID, userID uuid.UUID) (*models.CourseReview, error) {
+	review, err := s.review(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, errors.New("you can only change your own review")
+	}
+	return review, nil
+}
+
+// reload loads a review with its author
+func (s *ReviewService) reload(reviewID uuid.UUID) (*ReviewResponse, error) {
+	review, err := s.review(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	response := newReviewResponse(review)
+	return &response, nil
+}
+
+// invalidateCourse drops the cached copies of a course whose rating changed
+func (s *ReviewService) invalidateCourse(courseID uuid.UUID) {
+	invalidateCourses(s.cache, courseID)
+}
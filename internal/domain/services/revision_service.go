@@ -0,0 +1,265 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevisionService lists and compares the revisions of courses and lessons.
+// Revisions are written by the course and lesson services with every change.
+type RevisionService struct {
+	revisionRepo *repositories.ContentRevisionRepository
+	courseRepo   *repositories.CourseRepository
+	lessonRepo   *repositories.LessonRepository
+}
+
+// NewRevisionService creates a new revision service
+func NewRevisionService() *RevisionService {
+	return &RevisionService{
+		revisionRepo: repositories.NewContentRevisionRepository(),
+		courseRepo:   repositories.NewCourseRepository(),
+		lessonRepo:   repositories.NewLessonRepository(),
+	}
+}
+
+// RevisionResponse represents a revision of a course or lesson
+type RevisionResponse struct {
+	ID              uuid.UUID       `json:"id"`
+	Version         int             `json:"version"`
+	Action          string          `json:"action"`
+	RestoredVersion *int            `json:"restored_version,omitempty"`
+	Snapshot        json.RawMessage `json:"snapshot" swaggertype:"object"`
+	AuthorID        *uuid.UUID      `json:"author_id,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// RevisionDiffResponse lists the fields that differ between two revisions
+type RevisionDiffResponse struct {
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes []FieldChangeResponse `json:"changes"`
+}
+
+// FieldChangeResponse represents a field whose value differs between two revisions
+type FieldChangeResponse struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// courseSnapshot is the revisioned content of a course
+type courseSnapshot struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Thumbnail   string  `json:"thumbnail"`
+	Price       float64 `json:"price"`
+	Level       string  `json:"level"`
+	Duration    int     `json:"duration"`
+	Category    string  `json:"category"`
+}
+
+// newCourseSnapshot takes a snapshot of the content of a course
+func newCourseSnapshot(course *models.Course) courseSnapshot {
+	return courseSnapshot{
+		Title:       course.Title,
+		Description: course.Description,
+		Thumbnail:   course.Thumbnail,
+		Price:       course.Price,
+		Level:       course.Level,
+		Duration:    course.Duration,
+		Category:    course.Category,
+	}
+}
+
+// applyTo restores the snapshot content on a course
+func (s courseSnapshot) applyTo(course *models.Course) {
+	course.Title = s.Title
+	course.Description = s.Description
+	course.Thumbnail = s.Thumbnail
+	course.Price = s.Price
+	course.Level = s.Level
+	course.Duration = s.Duration
+	course.Category = s.Category
+}
+
+// lessonSnapshot is the revisioned content of a lesson
+type lessonSnapshot struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	VideoURL    string `json:"video_url"`
+	VideoID     string `json:"video_id"`
+	Duration    int    `json:"duration"`
+	OrderNumber int    `json:"order_number"`
+}
+
+// newLessonSnapshot takes a snapshot of the content of a lesson
+func newLessonSnapshot(lesson *models.Lesson) lessonSnapshot {
+	return lessonSnapshot{
+		Title:       lesson.Title,
+		Description: lesson.Description,
+		VideoURL:    lesson.VideoURL,
+		VideoID:     lesson.VideoID,
+		Duration:    lesson.Duration,
+		OrderNumber: lesson.OrderNumber,
+	}
+}
+
+// applyTo restores the snapshot content on a lesson
+func (s lessonSnapshot) applyTo(lesson *models.Lesson) {
+	lesson.Title = s.Title
+	lesson.Description = s.Description
+	lesson.VideoURL = s.VideoURL
+	lesson.VideoID = s.VideoID
+	lesson.Duration = s.Duration
+	lesson.OrderNumber = s.OrderNumber
+}
+
+// newRevision builds the revision of a change; the repository sets the entity ID
+// and version when it stores it
+func newRevision(entityType, action string, authorID uuid.UUID, snapshot interface{}) (*models.ContentRevision, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := &models.ContentRevision{
+		EntityType: entityType,
+		Action:     action,
+		Snapshot:   data,
+	}
+	if authorID != uuid.Nil {
+		revision.AuthorID = &authorID
+	}
+	return revision, nil
+}
+
+// getRevision loads one revision of a course or lesson
+func getRevision(revisionRepo *repositories.ContentRevisionRepository, entityType string, entityID uuid.UUID, version int) (*models.ContentRevision, error) {
+	revision, err := revisionRepo.GetByVersion(entityType, entityID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("revision not found")
+		}
+		return nil, err
+	}
+	return revision, nil
+}
+
+// ListCourseRevisions lists the revisions of a course, newest first
+func (s *RevisionService) ListCourseRevisions(courseID, userID uuid.UUID, role string) ([]RevisionResponse, error) {
+	if _, err := checkCourseInstructor(s.courseRepo, courseID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.list(models.RevisionEntityCourse, courseID)
+}
+
+// DiffCourseRevisions compares two revisions of a course field by field
+func (s *RevisionService) DiffCourseRevisions(courseID, userID uuid.UUID, role string, from, to int) (*RevisionDiffResponse, error) {
+	if _, err := checkCourseInstructor(s.courseRepo, courseID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.diff(models.RevisionEntityCourse, courseID, from, to)
+}
+
+// ListLessonRevisions lists the revisions of a lesson, newest first
+func (s *RevisionService) ListLessonRevisions(lessonID, userID uuid.UUID, role string) ([]RevisionResponse, error) {
+	if err := s.checkLessonAccess(lessonID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.list(models.RevisionEntityLesson, lessonID)
+}
+
+// DiffLessonRevisions compares two revisions of a lesson field by field
+func (s *RevisionService) DiffLessonRevisions(lessonID, userID uuid.UUID, role string, from, to int) (*RevisionDiffResponse, error) {
+	if err := s.checkLessonAccess(lessonID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.diff(models.RevisionEntityLesson, lessonID, from, to)
+}
+
+// list lists the revisions of a course or lesson
+func (s *RevisionService) list(entityType string, entityID uuid.UUID) ([]RevisionResponse, error) {
+	revisions, err := s.revisionRepo.GetByEntity(entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]RevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		responses = append(responses, RevisionResponse{
+			ID:              revision.ID,
+			Version:         revision.Version,
+			Action:          revision.Action,
+			RestoredVersion: revision.RestoredVersion,
+			Snapshot:        revision.Snapshot,
+			AuthorID:        revision.AuthorID,
+			CreatedAt:       revision.CreatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// diff lists the fields whose values differ between two revisions, by field name
+func (s *RevisionService) diff(entityType string, entityID uuid.UUID, from, to int) (*RevisionDiffResponse, error) {
+	fromRevision, err := getRevision(s.revisionRepo, entityType, entityID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := getRevision(s.revisionRepo, entityType, entityID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromFields, toFields map[string]interface{}
+	if err := json.Unmarshal(fromRevision.Snapshot, &fromFields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(toRevision.Snapshot, &toFields); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(toFields))
+	for field := range toFields {
+		fields = append(fields, field)
+	}
+	for field := range fromFields {
+		if _, ok := toFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []FieldChangeResponse{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(fromFields[field], toFields[field]) {
+			changes = append(changes, FieldChangeResponse{
+				Field: field,
+				From:  fromFields[field],
+				To:    toFields[field],
+			})
+		}
+	}
+
+	return &RevisionDiffResponse{From: from, To: to, Changes: changes}, nil
+}
+
+// checkLessonAccess checks that the lesson exists and that the user may manage its course
+func (s *RevisionService) checkLessonAccess(lessonID, userID uuid.UUID, role string) error {
+	lesson, err := s.lessonRepo.GetByID(lessonID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("lesson not found")
+		}
+		return err
+	}
+	_, err = checkCourseInstructor(s.courseRepo, lesson.CourseID, userID, role)
+	return err
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRole is the learner role every new account starts with
+	DefaultRole = "user"
+	// rolePermissionsCacheExpiration is how long the permissions of a role are cached
+	rolePermissionsCacheExpiration = 10 * time.Minute
+)
+
+type RoleService struct {
+	roleRepo     *repositories.RoleRepository
+	userRepo     *repositories.UserRepository
+	tokenService *TokenService
+	cache        *redis.Cache
+}
+
+// NewRoleService creates a new role service
+func NewRoleService() *RoleService {
+	return &RoleService{
+		roleRepo:     repositories.NewRoleRepository(),
+		userRepo:     repositories.NewUserRepository(),
+		tokenService: NewTokenService(),
+		cache:        redis.NewCache(),
+	}
+}
+
+// RoleResponse represents a role and the permissions it grants
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleChangeResponse represents an audited role change
+type RoleChangeResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	OldRole   string     `json:"old_role"`
+	NewRole   string     `json:"new_role"`
+	ChangedBy *uuid.UUID `json:"changed_by,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// GrantRoleRequest represents the grant role request
+type GrantRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// RevokeRoleRequest represents the revoke role request
+type RevokeRoleRequest struct {
+	Reason string `json:"reason"`
+}
+
+// List lists all roles with their permissions
+func (s *RoleService) List() ([]RoleResponse, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	roleResponses := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		permissions := make([]string, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			permissions = append(permissions, permission.Name)
+		}
+		roleResponses = append(roleResponses, RoleResponse{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: permissions,
+		})
+	}
+
+	return roleResponses, nil
+}
+
+// HasPermission checks whether a role grants a permission
+func (s *RoleService) HasPermission(role, permission string) (bool, error) {
+	permissions, err := s.getPermissions(role)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Grant gives a user a new role. The change is recorded along with the admin who made it.
+func (s *RoleService) Grant(actorID, userID uuid.UUID, req GrantRoleRequest, audit *AuditRecord) (*UserResponse, error) {
+	if _, err := s.roleRepo.GetByName(req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	user, err := s.getTargetUser(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == req.Role {
+		return nil, errors.New("user already has this role")
+	}
+
+	return s.assign(actorID, user, req.Role, req.Reason, models.AuditActionRoleGrant, audit)
+}
+
+// Revoke takes a role away from a user, returning them to the default role
+func (s *RoleService) Revoke(actorID, userID uuid.UUID, role string, req RevokeRoleRequest, audit *AuditRecord) (*UserResponse, error) {
+	if role == DefaultRole {
+		return nil, errors.New("cannot revoke the default role")
+	}
+
+	user, err := s.getTargetUser(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role != role {
+		return nil, errors.New("user does not have this role")
+	}
+
+	return s.assign(actorID, user, DefaultRole, req.Reason, models.AuditActionRoleRevoke, audit)
+}
+
+// ListChanges lists the role changes of a user, newest first
+func (s *RoleService) ListChanges(userID uuid.UUID) ([]RoleChangeResponse, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	changes, err := s.roleRepo.GetRoleChangesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changeResponses := make([]RoleChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		changeResponses = append(changeResponses, RoleChangeResponse{
+			ID:        change.ID,
+			UserID:    change.UserID,
+			OldRole:   change.OldRole,
+			NewRole:   change.NewRole,
+			ChangedBy: change.ChangedBy,
+			Reason:    change.Reason,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+
+	return changeResponses, nil
+}
+
+// getTargetUser loads the user whose role is being changed. Admins may not change their own role.
+func (s *RoleService) getTargetUser(actorID, userID uuid.UUID) (*models.User, error) {
+	if actorID == userID {
+		return nil, errors.New("you cannot change your own role")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// assign changes the role of a user and records the change
+func (s *RoleService) assign(actorID uuid.UUID, user *models.User, role, reason, action string, audit *AuditRecord) (*UserResponse, error) {
+	change := &models.RoleChange{
+		UserID:    user.ID,
+		OldRole:   user.Role,
+		NewRole:   role,
+		ChangedBy: &actorID,
+		Reason:    reason,
+	}
+
+	event := audit.Change(action, models.AuditTargetUser, user.ID,
+		map[string]string{"role": change.OldRole},
+		map[string]string{"role": change.NewRole, "reason": change.Reason},
+	)
+	if err := s.roleRepo.AssignUserRole(change, event); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Role of user %s changed from %s to %s by %s", user.ID, change.OldRole, change.NewRole, actorID)
+
+	// Access tokens carry the role, so the old ones must not be used any more
+	if err := s.tokenService.RevokeUserTokens(user.ID); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	response := newUserResponse(user)
+	return &response, nil
+}
+
+// getPermissions gets the permission names of a role, using the cache when possible
+func (s *RoleService) getPermissions(role string) ([]string, error) {
+	ctx := context.Background()
+	cacheKey := "role:permissions:" + role
+
+	var permissions []string
+	if err := s.cache.GetJSON(ctx, cacheKey, &permissions); err == nil {
+		return permissions, nil
+	}
+
+	permissions, err := s.roleRepo.GetPermissionNames(role)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.SetJSON(ctx, cacheKey, permissions, rolePermissionsCacheExpiration)
+
+	return permissions, nil
+}
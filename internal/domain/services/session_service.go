@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionService struct {
+	refreshTokenRepo *repositories.RefreshTokenRepository
+	userRepo         *repositories.UserRepository
+	tokenService     *TokenService
+}
+
+// NewSessionService creates a new session service
+func NewSessionService() *SessionService {
+	return &SessionService{
+		refreshTokenRepo: repositories.NewRefreshTokenRepository(),
+		userRepo:         repositories.NewUserRepository(),
+		tokenService:     NewTokenService(),
+	}
+}
+
+// SessionResponse represents a signed-in device. The ID stays the same when
+// the refresh token of the session is rotated.
+type SessionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	IPAddress   string    `json:"ip_address,omitempty"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// ListByUser lists the live sessions of a user. currentSessionID marks the
+// session making the request, if any.
+func (s *SessionService) ListByUser(userID, currentSessionID uuid.UUID) ([]SessionResponse, error) {
+	if err := s.checkUser(userID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.refreshTokenRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]SessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, SessionResponse{
+			ID:          token.FamilyID,
+			DeviceLabel: token.DeviceLabel,
+			UserAgent:   token.UserAgent,
+			IPAddress:   token.IPAddress,
+			LastUsedAt:  token.LastUsedAt,
+			ExpiresAt:   token.ExpiresAt,
+			Current:     currentSessionID != uuid.Nil && token.FamilyID == currentSessionID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// Revoke signs a user out of one session
+func (s *SessionService) Revoke(userID, sessionID uuid.UUID) error {
+	if err := s.checkUser(userID); err != nil {
+		return err
+	}
+
+	deleted, err := s.refreshTokenRepo.DeleteByUserIDAndFamilyID(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("session not found")
+	}
+
+	// Access tokens already issued for the session stop working too
+	return s.tokenService.RevokeSession(sessionID)
+}
+
+// RevokeAll signs a user out everywhere
+func (s *SessionService) RevokeAll(userID uuid.UUID) error {
+	if err := s.checkUser(userID); err != nil {
+		return err
+	}
+
+	if err := s.tokenService.RevokeUserTokens(userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.DeleteByUserID(userID)
+}
+
+// checkUser checks that the user exists
+func (s *SessionService) checkUser(userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// siweNoncePurpose namespaces Sign-In with Ethereum nonces in the cache
+	siweNoncePurpose = "siwe"
+	// siweClockSkew is how far in the future an Issued At time may be
+	siweClockSkew = 5 * time.Minute
+)
+
+// SIWEService handles Sign-In with Ethereum (EIP-4361)
+type SIWEService struct {
+	walletRepo  *repositories.UserWalletRepository
+	authService *AuthService
+	cache       *redis.Cache
+}
+
+// NewSIWEService creates a new Sign-In with Ethereum service
+func NewSIWEService() *SIWEService {
+	return &SIWEService{
+		walletRepo:  repositories.NewUserWalletRepository(),
+		authService: NewAuthService(),
+		cache:       redis.NewCache(),
+	}
+}
+
+// NonceResponse represents a single-use nonce to embed in a signed message
+type NonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SIWEVerifyRequest represents the Sign-In with Ethereum verify request
+type SIWEVerifyRequest struct {
+	Message    string `json:"message" binding:"required"`
+	Signature  string `json:"signature" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// Nonce issues a nonce for a Sign-In with Ethereum message
+func (s *SIWEService) Nonce() (*NonceResponse, error) {
+	return issueNonce(s.cache, siweNoncePurpose, true)
+}
+
+// Verify checks a signed Sign-In with Ethereum message and logs the signer in,
+// creating a wallet-only account on first sign in
+func (s *SIWEService) Verify(req SIWEVerifyRequest, client ClientInfo) (*TokenResponse, error) {
+	var valid bool
+	msg, err := verifySIWEMessage(s.cache, siweNoncePurpose, req.Message, req.Signature, &valid)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid or expired nonce")
+	}
+
+	var user *models.User
+	wallet, err := s.walletRepo.GetByAddress(strings.ToLower(msg.Address))
+	if err == nil {
+		// The wallet of a deleted user stays linked until the user is purged
+		if wallet.User.ID == uuid.Nil {
+			return nil, errors.New("this account has been deleted")
+		}
+		user = &wallet.User
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = s.createWalletUser(msg)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return s.authService.beginSession(user, client)
+}
+
+// createWalletUser creates a learner account identified only by the wallet
+// that signed the message
+func (s *SIWEService) createWalletUser(msg *utils.SIWEMessage) (*models.User, error) {
+	address := strings.ToLower(msg.Address)
+	checksummed := utils.ChecksumAddress(address)
+	now := time.Now()
+
+	user := &models.User{
+		FullName: checksummed[:6] + "…" + checksummed[len(checksummed)-4:],
+		Role:     DefaultRole,
+	}
+	wallet := &models.UserWallet{
+		Address:    address,
+		ChainID:    msg.ChainID,
+		IsPrimary:  true,
+		VerifiedAt: &now,
+	}
+
+	if err := s.walletRepo.CreateUserWithWallet(user, wallet); err != nil {
+		// Another request may have created the account concurrently
+		if existing, getErr := s.walletRepo.GetByAddress(address); getErr == nil {
+			return &existing.User, nil
+		}
+		return nil, err
+	}
+
+	user.Wallets = []models.UserWallet{*wallet}
+	return user, nil
+}
+
+// issueNonce stores a random single-use nonce for a purpose. The value is
+// handed back when the nonce is consumed.
+func issueNonce(cache *redis.Cache, purpose string, value interface{}) (*NonceResponse, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	nonce := hex.EncodeToString(b)
+
+	expiration := time.Duration(config.SIWESetting.NonceExpireTime) * time.Second
+	ctx := context.Background()
+	if err := cache.SetNonce(ctx, purpose, nonce, value, expiration); err != nil {
+		return nil, err
+	}
+
+	return &NonceResponse{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(expiration),
+	}, nil
+}
+
+// verifySIWEMessage parses an EIP-4361 message, checks its domain, chain ID
+// and validity window, verifies that the signature was made by the address in
+// the message and consumes its nonce, loading the nonce value into dest
+func verifySIWEMessage(cache *redis.Cache, purpose, message, signature string, dest interface{}) (*utils.SIWEMessage, error) {
+	msg, err := utils.ParseSIWEMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Version != "1" {
+		return nil, errors.New("unsupported SIWE message version")
+	}
+	if config.SIWESetting.Domain != "" && msg.Domain != config.SIWESetting.Domain {
+		return nil, errors.New("message domain does not match")
+	}
+	if len(config.SIWESetting.ChainIDs) > 0 && !slices.Contains(config.SIWESetting.ChainIDs, msg.ChainID) {
+		return nil, errors.New("chain ID is not supported")
+	}
+
+	now := time.Now()
+	if msg.IssuedAt.After(now.Add(siweClockSkew)) {
+		return nil, errors.New("message is not valid yet")
+	}
+	if msg.NotBefore != nil && msg.NotBefore.After(now) {
+		return nil, errors.New("message is not valid yet")
+	}
+	if msg.ExpirationTime != nil && !msg.ExpirationTime.After(now) {
+		return nil, errors.New("message has expired")
+	}
+
+	signer, err := utils.RecoverPersonalSignAddress(message, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(signer, msg.Address) {
+		return nil, errors.New("signature does not match address")
+	}
+
+	// The nonce is consumed last so that invalid attempts cannot burn it
+	ctx := context.Background()
+	if err := cache.ConsumeNonce(ctx, purpose, msg.Nonce, dest); err != nil {
+		return nil, errors.New("invalid or expired nonce")
+	}
+
+	return msg, nil
+}
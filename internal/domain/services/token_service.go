@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/google/uuid"
+)
+
+// TokenService revokes access tokens before they expire
+type TokenService struct {
+	cache *redis.Cache
+}
+
+// NewTokenService creates a new token service
+func NewTokenService() *TokenService {
+	return &TokenService{
+		cache: redis.NewCache(),
+	}
+}
+
+// RevokeAccessToken denylists a single access token until it expires
+func (s *TokenService) RevokeAccessToken(claims *utils.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	expiration := time.Until(claims.ExpiresAt.Time)
+	if expiration <= 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	return s.cache.DenyToken(ctx, claims.ID, expiration)
+}
+
+// RevokeSession denylists every access token issued for a session
+func (s *TokenService) RevokeSession(sessionID uuid.UUID) error {
+	ctx := context.Background()
+	return s.cache.DenySession(ctx, sessionID.String(), accessTokenLifetime())
+}
+
+// RevokeUserTokens invalidates every access token issued to a user so far
+func (s *TokenService) RevokeUserTokens(userID uuid.UUID) error {
+	ctx := context.Background()
+	return s.cache.SetTokensValidAfter(ctx, userID.String(), time.Now(), accessTokenLifetime())
+}
+
+// IsRevoked checks whether an access token was revoked
+func (s *TokenService) IsRevoked(claims *utils.Claims) (bool, error) {
+	ctx := context.Background()
+	denied, validAfter, err := s.cache.GetTokenRevocation(ctx, claims.ID, claims.SessionID.String(), claims.UserID.String())
+	if err != nil {
+		return false, err
+	}
+	if denied {
+		return true, nil
+	}
+
+	if !validAfter.IsZero() {
+		// Issue times have second precision, so only tokens from an earlier second are rejected
+		if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(validAfter) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// accessTokenLifetime is the longest an access token stays valid
+func accessTokenLifetime() time.Duration {
+	return time.Duration(config.AppSetting.TokenExpireTime) * time.Hour
+}
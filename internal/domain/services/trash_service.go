@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Types of deleted records kept in the trash
+const (
+	TrashTypeCourse   = "course"
+	TrashTypeLesson   = "lesson"
+	TrashTypeUser     = "user"
+	TrashTypeCategory = "category"
+)
+
+// trashPurgeKey makes sure only one instance purges the trash per interval
+const trashPurgeKey = "trash:purge"
+
+// TrashService lists and restores soft-deleted courses, lessons, users and
+// categories, and purges them for good once the retention period has passed
+type TrashService struct {
+	trashRepo    *repositories.TrashRepository
+	courseRepo   *repositories.CourseRepository
+	lessonRepo   *repositories.LessonRepository
+	userRepo     *repositories.UserRepository
+	categoryRepo *repositories.CategoryRepository
+	reviewRepo   *repositories.CourseReviewRepository
+	cache        *redis.Cache
+}
+
+// NewTrashService creates a new trash service
+func NewTrashService() *TrashService {
+	return &TrashService{
+		trashRepo:    repositories.NewTrashRepository(),
+		courseRepo:   repositories.NewCourseRepository(),
+		lessonRepo:   repositories.NewLessonRepository(),
+		userRepo:     repositories.NewUserRepository(),
+		categoryRepo: repositories.NewCategoryRepository(),
+		reviewRepo:   repositories.NewCourseReviewRepository(),
+		cache:        redis.NewCache(),
+	}
+}
+
+// TrashItemResponse represents a deleted record
+type TrashItemResponse struct {
+	Type      string     `json:"type"`
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email,omitempty"`
+	CourseID  *uuid.UUID `json:"course_id,omitempty"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"`
+}
+
+// newTrashItem builds a trash item, with the time it will be purged at
+func newTrashItem(trashType string, id uuid.UUID, name string, deletedAt gorm.DeletedAt) TrashItemResponse {
+	item := TrashItemResponse{
+		Type:      trashType,
+		ID:        id,
+		Name:      name,
+		DeletedAt: deletedAt.Time,
+	}
+	if days := config.TrashSetting.RetentionDays; days > 0 {
+		purgeAt := deletedAt.Time.AddDate(0, 0, days)
+		item.PurgeAt = &purgeAt
+	}
+	return item
+}
+
+// List lists the deleted records of a type, most recently deleted first
+func (s *TrashService) List(trashType string, page, pageSize int) ([]TrashItemResponse, int64, error) {
+	items := []TrashItemResponse{}
+
+	switch trashType {
+	case TrashTypeCourse:
+		var courses []models.Course
+		count, err := s.trashRepo.List(&models.Course{}, &courses, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, course := range courses {
+			items = append(items, newTrashItem(trashType, course.ID, course.Title, course.DeletedAt))
+		}
+		return items, count, nil
+
+	case TrashTypeLesson:
+		var lessons []models.Lesson
+		count, err := s.trashRepo.List(&models.Lesson{}, &lessons, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, lesson := range lessons {
+			item := newTrashItem(trashType, lesson.ID, lesson.Title, lesson.DeletedAt)
+			courseID := lesson.CourseID
+			item.CourseID = &courseID
+			items = append(items, item)
+		}
+		return items, count, nil
+
+	case TrashTypeUser:
+		var users []models.User
+		count, err := s.trashRepo.List(&models.User{}, &users, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range users {
+			item := newTrashItem(trashType, users[i].ID, users[i].FullName, users[i].DeletedAt)
+			item.Email = users[i].EmailAddress()
+			items = append(items, item)
+		}
+		return items, count, nil
+
+	case TrashTypeCategory:
+		var categories []models.Category
+		count, err := s.trashRepo.List(&models.Category{}, &categories, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, category := range categories {
+			items = append(items, newTrashItem(trashType, category.ID, category.Name, category.DeletedAt))
+		}
+		return items, count, nil
+	}
+
+	return nil, 0, errors.New("invalid trash type")
+}
+
+// Restore takes a deleted record out of the trash
+func (s *TrashService) Restore(trashType string, id uuid.UUID, audit *AuditRecord) error {
+	switch trashType {
+	case TrashTypeCourse:
+		return s.restoreCourse(id, audit)
+	case TrashTypeLesson:
+		return s.restoreLesson(id, audit)
+	case TrashTypeUser:
+		return s.restoreUser(id, audit)
+	case TrashTypeCategory:
+		return s.restoreCategory(id, audit)
+	}
+	return errors.New("invalid trash type")
+}
+
+// restoreCourse restores a course with the lessons deleted together with it.
+// Its enrollments, progress and reviews were kept and show up again.
+func (s *TrashService) restoreCourse(id uuid.UUID, audit *AuditRecord) error {
+	var course models.Course
+	if err := s.trashRepo.GetByID(&course, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("course not found in trash")
+		}
+		return err
+	}
+
+	event := auditRestore(audit, TrashTypeCourse, course.ID, course.DeletedAt)
+	if err := s.trashRepo.RestoreCourse(&course, event); err != nil {
+		return err
+	}
+	invalidateCourses(s.cache, course.ID)
+
+	return nil
+}
+
+// restoreLesson restores a lesson of a live course, in its old position
+func (s *TrashService) restoreLesson(id uuid.UUID, audit *AuditRecord) error {
+	var lesson models.Lesson
+	if err := s.trashRepo.GetByID(&lesson, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("lesson not found in trash")
+		}
+		return err
+	}
+
+	if _, err := s.courseRepo.GetByID(lesson.CourseID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("the course of this lesson is deleted, restore the course first")
+		}
+		return err
+	}
+
+	_, err := s.lessonRepo.GetByCourseAndOrderNumber(lesson.CourseID, lesson.OrderNumber)
+	if err == nil {
+		return errors.New("lesson with this order number already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	event := auditRestore(audit, TrashTypeLesson, lesson.ID, lesson.DeletedAt)
+	if err := s.trashRepo.Restore(&models.Lesson{}, lesson.ID, event); err != nil {
+		return err
+	}
+	invalidateCourses(s.cache, lesson.CourseID)
+
+	return nil
+}
+
+// restoreUser restores a user whose email address has not been taken since.
+// The user's reviews count towards the course ratings again.
+func (s *TrashService) restoreUser(id uuid.UUID, audit *AuditRecord) error {
+	var user models.User
+	if err := s.trashRepo.GetByID(&user, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found in trash")
+		}
+		return err
+	}
+
+	if user.Email != nil {
+		_, err := s.userRepo.GetByEmail(*user.Email)
+		if err == nil {
+			return errors.New("email already exists")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	event := auditRestore(audit, TrashTypeUser, user.ID, user.DeletedAt)
+	if err := s.trashRepo.Restore(&models.User{}, user.ID, event); err != nil {
+		return err
+	}
+
+	courseIDs, err := s.reviewRepo.RefreshRatingsByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	invalidateCourses(s.cache, courseIDs...)
+
+	return nil
+}
+
+// restoreCategory restores a category whose name and slug have not been taken since
+func (s *TrashService) restoreCategory(id uuid.UUID, audit *AuditRecord) error {
+	var category models.Category
+	if err := s.trashRepo.GetByID(&category, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("category not found in trash")
+		}
+		return err
+	}
+
+	_, err := s.categoryRepo.GetByName(category.Name)
+	if err == nil {
+		return errors.New("category name already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	_, err = s.categoryRepo.GetBySlug(category.Slug)
+	if err == nil {
+		return errors.New("slug already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	event := auditRestore(audit, TrashTypeCategory, category.ID, category.DeletedAt)
+	if err := s.trashRepo.Restore(&models.Category{}, category.ID, event); err != nil {
+		return err
+	}
+	s.cache.Delete(context.Background(), "categories:all")
+
+	return nil
+}
+
+// auditRestore describes a restore as an audit event
+func auditRestore(audit *AuditRecord, trashType string, id uuid.UUID, deletedAt gorm.DeletedAt) *models.AuditEvent {
+	return audit.Change(models.AuditActionRestore, trashType, id,
+		map[string]interface{}{"deleted_at": deletedAt.Time},
+		map[string]interface{}{"deleted_at": nil},
+	)
+}
+
+// RunPurgeJob purges expired records from the trash every PurgeInterval until
+// the context is cancelled. It does nothing when the retention period is 0.
+func (s *TrashService) RunPurgeJob(ctx context.Context) {
+	if config.TrashSetting.RetentionDays <= 0 {
+		log.Printf("Trash retention is disabled, deleted records are kept forever")
+		return
+	}
+
+	interval := time.Duration(config.TrashSetting.PurgeInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.purge(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge hard-deletes the records deleted longer ago than the retention period
+func (s *TrashService) purge(ctx context.Context, interval time.Duration) {
+	// Every instance runs the job, the first one to claim the interval purges
+	claimed, err := s.cache.SetOnce(ctx, trashPurgeKey, interval)
+	if err != nil {
+		log.Printf("Failed to claim trash purge: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -config.TrashSetting.RetentionDays)
+	result, err := s.trashRepo.Purge(cutoff)
+	if err != nil {
+		log.Printf("Failed to purge trash: %v", err)
+		return
+	}
+
+	if result.Lessons+result.Courses+result.Categories+result.Users > 0 {
+		log.Printf("Purged trash: %d courses, %d lessons, %d categories, %d users",
+			result.Courses, result.Lessons, result.Categories, result.Users)
+	}
+}
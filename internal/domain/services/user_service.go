@@ -3,6 +3,8 @@ package services
 import (
 	"errors"
 
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
 	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
 	"github.com/0xBoji/web3-edu-core/internal/utils"
 	"github.com/google/uuid"
@@ -10,13 +12,25 @@ import (
 )
 
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo         *repositories.UserRepository
+	refreshTokenRepo *repositories.RefreshTokenRepository
+	reviewRepo       *repositories.CourseReviewRepository
+	auditRepo        *repositories.AuditEventRepository
+	tokenService     *TokenService
+	lockoutService   *LockoutService
+	cache            *redis.Cache
 }
 
 // NewUserService creates a new user service
 func NewUserService() *UserService {
 	return &UserService{
-		userRepo: repositories.NewUserRepository(),
+		userRepo:         repositories.NewUserRepository(),
+		refreshTokenRepo: repositories.NewRefreshTokenRepository(),
+		reviewRepo:       repositories.NewCourseReviewRepository(),
+		auditRepo:        repositories.NewAuditEventRepository(),
+		tokenService:     NewTokenService(),
+		lockoutService:   NewLockoutService(),
+		cache:            redis.NewCache(),
 	}
 }
 
@@ -25,10 +39,15 @@ func NewUserService() *UserService {
 // UpdateUserRequest represents the update user request
 type UpdateUserRequest struct {
 	FullName       string `json:"full_name"`
-	Password       string `json:"password"`
 	ProfilePicture string `json:"profile_picture"`
 }
 
+// ChangePasswordRequest represents the change password request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
 // GetByID gets a user by ID
 func (s *UserService) GetByID(id uuid.UUID) (*UserResponse, error) {
 	user, err := s.userRepo.GetByID(id)
@@ -39,17 +58,12 @@ func (s *UserService) GetByID(id uuid.UUID) (*UserResponse, error) {
 		return nil, err
 	}
 
-	return &UserResponse{
-		ID:             user.ID,
-		Email:          user.Email,
-		FullName:       user.FullName,
-		Role:           user.Role,
-		ProfilePicture: user.ProfilePicture,
-	}, nil
+	response := newUserResponse(user)
+	return &response, nil
 }
 
 // Update updates a user
-func (s *UserService) Update(id uuid.UUID, req UpdateUserRequest) (*UserResponse, error) {
+func (s *UserService) Update(id uuid.UUID, req UpdateUserRequest, audit *AuditRecord) (*UserResponse, error) {
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -57,6 +71,7 @@ func (s *UserService) Update(id uuid.UUID, req UpdateUserRequest) (*UserResponse
 		}
 		return nil, err
 	}
+	before := newUserResponse(user)
 
 	// Update fields
 	if req.FullName != "" {
@@ -65,26 +80,100 @@ func (s *UserService) Update(id uuid.UUID, req UpdateUserRequest) (*UserResponse
 	if req.ProfilePicture != "" {
 		user.ProfilePicture = req.ProfilePicture
 	}
-	if req.Password != "" {
-		hashedPassword, err := utils.HashPassword(req.Password)
-		if err != nil {
-			return nil, err
-		}
-		user.PasswordHash = hashedPassword
-	}
 
 	// Save user
-	if err := s.userRepo.Update(user); err != nil {
+	event := audit.Change(models.AuditActionUserUpdate, models.AuditTargetUser, user.ID, before, newUserResponse(user))
+	if err := s.userRepo.Update(user, event); err != nil {
 		return nil, err
 	}
 
-	return &UserResponse{
-		ID:             user.ID,
-		Email:          user.Email,
-		FullName:       user.FullName,
-		Role:           user.Role,
-		ProfilePicture: user.ProfilePicture,
-	}, nil
+	response := newUserResponse(user)
+	return &response, nil
+}
+
+// ChangePassword changes a user's password after verifying the current one,
+// and signs out every session except the current one. Wrong current passwords
+// are throttled per user like failed logins, so a stolen access token cannot
+// be used to guess the password.
+func (s *UserService) ChangePassword(id, sessionID uuid.UUID, req ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	// Check current password
+	attemptsKey := passwordChangeLockoutKey(user.ID)
+	if err := s.lockoutService.Check(attemptsKey); err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		if _, err := s.lockoutService.RecordFailure(attemptsKey); err != nil {
+			return err
+		}
+		return errors.New("current password is incorrect")
+	}
+	if err := s.lockoutService.Reset(attemptsKey); err != nil {
+		return err
+	}
+
+	// Enforce password policy
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		return err
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("new password must be different from the current password")
+	}
+
+	// Hash new password
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// Update user password
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(user, nil); err != nil {
+		return err
+	}
+
+	// Revoke every session except the current one. Tokens issued before
+	// sessions were tracked have no session, and sign out everywhere.
+	if sessionID != uuid.Nil {
+		return s.revokeOtherSessions(user.ID, sessionID)
+	}
+	if err := s.tokenService.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.DeleteByUserID(user.ID)
+}
+
+// passwordChangeLockoutKey identifies a user's password change attempts in the
+// lockout counters, apart from the login attempts of their email address
+func passwordChangeLockoutKey(userID uuid.UUID) string {
+	return "password_change:" + userID.String()
+}
+
+// revokeOtherSessions signs a user out of every session except the given one,
+// including the access tokens already issued for those sessions
+func (s *UserService) revokeOtherSessions(userID, keepSessionID uuid.UUID) error {
+	tokens, err := s.refreshTokenRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.FamilyID == keepSessionID {
+			continue
+		}
+		if err := s.tokenService.RevokeSession(token.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	return s.refreshTokenRepo.DeleteByUserIDExceptFamily(userID, keepSessionID)
 }
 
 // List lists all users
@@ -96,21 +185,41 @@ func (s *UserService) List(page, pageSize int) ([]UserResponse, int64, error) {
 
 	var userResponses []UserResponse
 	for _, user := range users {
-		userResponses = append(userResponses, UserResponse{
-			ID:             user.ID,
-			Email:          user.Email,
-			FullName:       user.FullName,
-			Role:           user.Role,
-			ProfilePicture: user.ProfilePicture,
-		})
+		userResponses = append(userResponses, newUserResponse(&user))
 	}
 
 	return userResponses, count, nil
 }
 
-// Delete deletes a user
-func (s *UserService) Delete(id uuid.UUID) error {
-	_, err := s.userRepo.GetByID(id)
+// Delete moves a user to the trash. Their enrollments, progress and reviews
+// are kept for a restore, but the reviews stop counting towards course ratings.
+func (s *UserService) Delete(id uuid.UUID, audit *AuditRecord) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	event := audit.Change(models.AuditActionUserDelete, models.AuditTargetUser, user.ID, newUserResponse(user), nil)
+	if err := s.userRepo.Delete(id, event); err != nil {
+		return err
+	}
+
+	courseIDs, err := s.reviewRepo.RefreshRatingsByUserID(id)
+	if err != nil {
+		return err
+	}
+	invalidateCourses(s.cache, courseIDs...)
+
+	// Access tokens of a deleted user must stop working immediately
+	return s.tokenService.RevokeUserTokens(id)
+}
+
+// Unlock clears the failed logins and any lockout of a user
+func (s *UserService) Unlock(id uuid.UUID, audit *AuditRecord) error {
+	user, err := s.userRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("user not found")
@@ -118,5 +227,20 @@ func (s *UserService) Delete(id uuid.UUID) error {
 		return err
 	}
 
-	return s.userRepo.Delete(id)
+	// Wallet-only accounts cannot log in with a password
+	if user.Email == nil {
+		return nil
+	}
+
+	if err := s.lockoutService.Reset(user.EmailAddress()); err != nil {
+		return err
+	}
+
+	// The lockout lives in Redis, so there is no transaction to record the
+	// unlock in. It is recorded right after, and a failure fails the request.
+	if event := audit.Change(models.AuditActionUserUnlock, models.AuditTargetUser, user.ID, nil, nil); event != nil {
+		return s.auditRepo.Create(event)
+	}
+
+	return nil
 }
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/internal/database/redis"
+	"github.com/0xBoji/web3-edu-core/internal/domain/models"
+	"github.com/0xBoji/web3-edu-core/internal/domain/repositories"
+	"github.com/0xBoji/web3-edu-core/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// walletLinkNoncePurpose namespaces wallet link challenges in the cache
+const walletLinkNoncePurpose = "wallet_link"
+
+type WalletService struct {
+	walletRepo   *repositories.UserWalletRepository
+	userRepo     *repositories.UserRepository
+	identityRepo *repositories.UserIdentityRepository
+	passkeyRepo  *repositories.WebAuthnCredentialRepository
+	cache        *redis.Cache
+}
+
+// NewWalletService creates a new wallet service
+func NewWalletService() *WalletService {
+	return &WalletService{
+		walletRepo:   repositories.NewUserWalletRepository(),
+		userRepo:     repositories.NewUserRepository(),
+		identityRepo: repositories.NewUserIdentityRepository(),
+		passkeyRepo:  repositories.NewWebAuthnCredentialRepository(),
+		cache:        redis.NewCache(),
+	}
+}
+
+// WalletResponse represents a linked wallet. Addresses are EIP-55 checksummed.
+type WalletResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Address    string     `json:"address"`
+	ChainID    int64      `json:"chain_id"`
+	Label      string     `json:"label,omitempty"`
+	IsPrimary  bool       `json:"is_primary"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// LinkWalletRequest represents the link wallet request. Message is an EIP-4361
+// message embedding a challenge from the wallet challenge endpoint.
+type LinkWalletRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+	Label     string `json:"label" binding:"max=100"`
+	Primary   bool   `json:"primary"`
+}
+
+// newWalletResponse maps a wallet model to a wallet response
+func newWalletResponse(wallet *models.UserWallet) WalletResponse {
+	return WalletResponse{
+		ID:         wallet.ID,
+		Address:    utils.ChecksumAddress(wallet.Address),
+		ChainID:    wallet.ChainID,
+		Label:      wallet.Label,
+		IsPrimary:  wallet.IsPrimary,
+		VerifiedAt: wallet.VerifiedAt,
+		CreatedAt:  wallet.CreatedAt,
+	}
+}
+
+// List lists the wallets of a user
+func (s *WalletService) List(userID uuid.UUID) ([]WalletResponse, error) {
+	wallets, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	walletResponses := make([]WalletResponse, 0, len(wallets))
+	for i := range wallets {
+		walletResponses = append(walletResponses, newWalletResponse(&wallets[i]))
+	}
+
+	return walletResponses, nil
+}
+
+// Challenge issues a nonce that only the given user can use to link a wallet
+func (s *WalletService) Challenge(userID uuid.UUID) (*NonceResponse, error) {
+	return issueNonce(s.cache, walletLinkNoncePurpose, userID.String())
+}
+
+// Link links the wallet that signed the challenge message to a user
+func (s *WalletService) Link(userID uuid.UUID, req LinkWalletRequest) (*WalletResponse, error) {
+	var owner string
+	msg, err := verifySIWEMessage(s.cache, walletLinkNoncePurpose, req.Message, req.Signature, &owner)
+	if err != nil {
+		return nil, err
+	}
+	if owner != userID.String() {
+		return nil, errors.New("invalid or expired nonce")
+	}
+
+	// An address may only ever belong to one account
+	address := strings.ToLower(msg.Address)
+	existing, err := s.walletRepo.GetByAddress(address)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil, errors.New("wallet is already linked to your account")
+		}
+		return nil, errors.New("wallet is already linked to another account")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// The first wallet is always primary
+	wallets, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	wallet := &models.UserWallet{
+		UserID:     userID,
+		Address:    address,
+		ChainID:    msg.ChainID,
+		Label:      req.Label,
+		IsPrimary:  req.Primary || len(wallets) == 0,
+		VerifiedAt: &now,
+	}
+
+	if err := s.walletRepo.Create(wallet); err != nil {
+		return nil, err
+	}
+
+	response := newWalletResponse(wallet)
+	return &response, nil
+}
+
+// Delete unlinks a wallet from a user. The last wallet of a wallet-only
+// account cannot be removed, since the user could not sign in any more.
+func (s *WalletService) Delete(userID, walletID uuid.UUID) error {
+	wallet, err := s.walletRepo.GetByID(walletID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("wallet not found")
+		}
+		return err
+	}
+	if wallet.UserID != userID {
+		return errors.New("wallet not found")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	identities, err := s.identityRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	passkeys, err := s.passkeyRepo.CountByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" && len(user.Wallets)+int(identities)+int(passkeys) <= 1 {
+		return errors.New("cannot remove the only sign-in method of this account")
+	}
+
+	return s.walletRepo.Delete(wallet)
+}
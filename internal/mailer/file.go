@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/google/uuid"
+)
+
+// FileMailer writes messages as .eml files for development
+type FileMailer struct {
+	dir  string
+	from mail.Address
+}
+
+// NewFileMailer creates a new file mailer
+func NewFileMailer(dir string) *FileMailer {
+	if dir == "" {
+		dir = "tmp/mail"
+	}
+	return &FileMailer{
+		dir: dir,
+		from: mail.Address{
+			Name:    config.MailSetting.FromName,
+			Address: config.MailSetting.From,
+		},
+	}
+}
+
+// Send writes the message to the output directory
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := buildMIME(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String()[:8])
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("Mail to %s written to %s", msg.To, path)
+	return nil
+}
+
+// LogMailer logs messages instead of delivering them. Only the recipient and
+// subject are logged, since bodies carry reset and sign-in links; use the
+// file driver to read full messages locally
+type LogMailer struct{}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send logs the message recipient and subject
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	log.Printf("Mail to %s, subject: %s", msg.To, msg.Subject)
+	return nil
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/0xBoji/web3-edu-core/config"
+)
+
+// Message represents an email message
+type Message struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer creates the mailer selected by the mail driver setting
+func NewMailer() Mailer {
+	switch strings.ToLower(config.MailSetting.Driver) {
+	case "smtp":
+		return NewSMTPMailer()
+	case "file":
+		return NewFileMailer(config.MailSetting.OutputDir)
+	case "log", "":
+		return NewLogMailer()
+	default:
+		log.Printf("Warning: unknown mail driver '%s', falling back to log", config.MailSetting.Driver)
+		return NewLogMailer()
+	}
+}
@@ -0,0 +1,145 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// LocalesDir is the directory where translation files are stored
+var LocalesDir = "locales"
+
+// DefaultLanguage is used when a translation is missing for the requested language
+const DefaultLanguage = "en"
+
+// templateData is passed to the email templates
+type templateData struct {
+	T    map[string]string
+	Data map[string]string
+}
+
+// Render renders a localized email template. The template name matches a file in
+// templates/ and, in camel case, a key in the "email" section of the locale files
+// (password_reset uses email.passwordReset). Placeholders in
+// translations like {name} are replaced with the matching data values.
+func Render(name, language string, data map[string]string) (*Message, error) {
+	translations, err := loadTranslations(name, language)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range translations {
+		for k, v := range data {
+			value = strings.ReplaceAll(value, "{"+k+"}", v)
+		}
+		translations[key] = value
+	}
+
+	td := templateData{T: translations, Data: data}
+
+	htmlTmpl, err := htmltemplate.ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	if err := htmlTmpl.ExecuteTemplate(&html, "layout", td); err != nil {
+		return nil, err
+	}
+
+	textTmpl, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	if err := textTmpl.Execute(&text, td); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject:  translations["subject"],
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
+
+// loadTranslations loads the shared and template specific email translations,
+// falling back to the default language for missing keys
+func loadTranslations(name, language string) (map[string]string, error) {
+	translations, err := readEmailSection(DefaultLanguage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if language != "" && language != DefaultLanguage {
+		if localized, err := readEmailSection(language, name); err == nil {
+			for k, v := range localized {
+				translations[k] = v
+			}
+		}
+	}
+
+	return translations, nil
+}
+
+// readEmailSection reads the "email" section of a locale file
+func readEmailSection(language, name string) (map[string]string, error) {
+	// Only allow plain language codes such as "en" or "vi"
+	if filepath.Base(language) != language {
+		return nil, errors.New("invalid language")
+	}
+
+	data, err := os.ReadFile(filepath.Join(LocalesDir, language+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var locale struct {
+		Email map[string]json.RawMessage `json:"email"`
+	}
+	if err := json.Unmarshal(data, &locale); err != nil {
+		return nil, err
+	}
+
+	translations := make(map[string]string)
+
+	// Shared strings such as the footer
+	if raw, ok := locale.Email["common"]; ok {
+		if err := json.Unmarshal(raw, &translations); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, ok := locale.Email[camelCase(name)]
+	if !ok {
+		return nil, errors.New("missing email translations for " + name)
+	}
+	var section map[string]string
+	if err := json.Unmarshal(raw, &section); err != nil {
+		return nil, err
+	}
+	for k, v := range section {
+		translations[k] = v
+	}
+
+	return translations, nil
+}
+
+// camelCase converts a snake_case template name to the camelCase used in locale files
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
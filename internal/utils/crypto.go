@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/0xBoji/web3-edu-core/config"
+)
+
+// EncryptSecret encrypts a secret for storage with AES-256-GCM
+func EncryptSecret(plaintext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(ciphertext string) (string, error) {
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid ciphertext")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// secretAEAD encrypts secrets at rest. It is set up by SetupSecretCipher.
+var secretAEAD cipher.AEAD
+
+// SetupSecretCipher derives the AES key for secrets at rest from the MFA
+// encryption key. Stored secrets cannot be decrypted once the key changes, so
+// it has to be set on its own rather than shared with the rotated JWT secret.
+func SetupSecretCipher() error {
+	key := config.MFASetting.EncryptionKey
+	if key == "" {
+		return errors.New("MFA encryption key is not set, set EncryptionKey in [mfa] or MFA_ENCRYPTION_KEY")
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return err
+	}
+	secretAEAD, err = cipher.NewGCM(block)
+	return err
+}
+
+// secretCipher returns the cipher for secrets at rest
+func secretCipher() (cipher.AEAD, error) {
+	if secretAEAD == nil {
+		return nil, errors.New("secret cipher is not set up")
+	}
+	return secretAEAD, nil
+}
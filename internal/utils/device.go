@@ -0,0 +1,62 @@
+package utils
+
+import "strings"
+
+// browserSignatures maps user agent fragments to browser names. Order matters:
+// Edge and Opera also advertise Chrome, and Chrome advertises Safari.
+var browserSignatures = []struct {
+	fragment string
+	name     string
+}{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+}
+
+// osSignatures maps user agent fragments to operating system names
+var osSignatures = []struct {
+	fragment string
+	name     string
+}{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceLabel builds a short human readable label such as "Chrome on macOS"
+// from a user agent string
+func DeviceLabel(userAgent string) string {
+	var browser, os string
+	for _, sig := range browserSignatures {
+		if strings.Contains(userAgent, sig.fragment) {
+			browser = sig.name
+			break
+		}
+	}
+	for _, sig := range osSignatures {
+		if strings.Contains(userAgent, sig.fragment) {
+			os = sig.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
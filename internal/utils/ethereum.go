@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 returns the legacy Keccak-256 hash used by Ethereum
+func Keccak256(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hash.Sum(nil)
+}
+
+// IsHexAddress checks whether a string is a 0x-prefixed 20 byte hex address
+func IsHexAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// ChecksumAddress returns the EIP-55 mixed-case form of an address
+func ChecksumAddress(address string) string {
+	lower := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := hex.EncodeToString(Keccak256([]byte(lower)))
+
+	result := []byte(lower)
+	for i, c := range result {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			result[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(result)
+}
+
+// RecoverPersonalSignAddress recovers the address that signed a message with
+// personal_sign (EIP-191). The address is returned in lower case.
+func RecoverPersonalSignAddress(message, signatureHex string) (string, error) {
+	signature, err := hex.DecodeString(strings.TrimPrefix(signatureHex, "0x"))
+	if err != nil || len(signature) != 65 {
+		return "", errors.New("invalid signature")
+	}
+
+	// Wallets use v = 27/28, some libraries 0/1
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", errors.New("invalid signature")
+	}
+
+	// The compact format is the recovery code followed by r and s
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], signature[:64])
+
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+	hash := Keccak256([]byte(prefix), []byte(message))
+
+	publicKey, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", errors.New("invalid signature")
+	}
+
+	// The address is the last 20 bytes of the hash of the uncompressed key without its prefix
+	address := Keccak256(publicKey.SerializeUncompressed()[1:])[12:]
+	return "0x" + hex.EncodeToString(address), nil
+}
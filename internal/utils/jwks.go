@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is a private key used to sign access tokens
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+var (
+	// signingKeys holds every loaded key by kid. All of them are accepted
+	// when verifying, so old keys keep working while a rotation overlaps.
+	signingKeys = map[string]*signingKey{}
+	// activeKey signs new tokens. When nil, tokens are signed with HS256.
+	activeKey *signingKey
+)
+
+// JWK represents a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet represents a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SetupKeys loads the signing keys from the configured keys directory. The
+// active key is generated as an Ed25519 key if it does not exist yet.
+func SetupKeys() error {
+	keys, err := loadKeys(config.JWTSetting.KeysDir)
+	if err != nil {
+		return err
+	}
+
+	activeID := config.JWTSetting.ActiveKeyID
+	if activeID != "" && keys[activeID] == nil {
+		key, err := generateKey(config.JWTSetting.KeysDir, activeID)
+		if err != nil {
+			return err
+		}
+		keys[activeID] = key
+		log.Printf("Generated Ed25519 signing key %q in %s", activeID, config.JWTSetting.KeysDir)
+	}
+
+	signingKeys = keys
+	activeKey = keys[activeID]
+	return nil
+}
+
+// PublicJWKS returns the public keys used to verify access tokens
+func PublicJWKS() JWKSet {
+	ids := make([]string, 0, len(signingKeys))
+	for id := range signingKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		key := signingKeys[id]
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+
+		switch public := key.private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// loadKeys loads every <kid>.pem private key in a directory
+func loadKeys(dir string) (map[string]*signingKey, error) {
+	keys := map[string]*signingKey{}
+	if dir == "" {
+		return keys, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return keys, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(entry.Name(), ".pem")
+		key, err := parsePrivateKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", entry.Name(), err)
+		}
+		keys[id] = key
+	}
+
+	return keys, nil
+}
+
+// parsePrivateKey parses a PEM encoded Ed25519 or RSA private key
+func parsePrivateKey(id string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var private any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := private.(type) {
+	case ed25519.PrivateKey:
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, private: private}, nil
+	case *rsa.PrivateKey:
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, private: private}, nil
+	default:
+		return nil, errors.New("unsupported key type, expected Ed25519 or RSA")
+	}
+}
+
+// generateKey generates an Ed25519 key and stores it as <kid>.pem
+func generateKey(dir, id string) (*signingKey, error) {
+	if dir == "" {
+		return nil, errors.New("no keys directory configured")
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0600); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{id: id, method: jwt.SigningMethodEdDSA, private: private}, nil
+}
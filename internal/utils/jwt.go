@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"time"
 
@@ -11,35 +13,67 @@ import (
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	SessionID uuid.UUID `json:"sid,omitzero"`
+	MFA       bool      `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token
-func GenerateToken(userID uuid.UUID, email, role string) (string, error) {
+// GenerateToken generates a JWT token. mfa records whether the session passed a second factor.
+func GenerateToken(userID uuid.UUID, email, role string, sessionID uuid.UUID, mfa bool) (string, error) {
 	expireTime := time.Now().Add(time.Duration(config.AppSetting.TokenExpireTime) * time.Hour)
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+		MFA:       mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    config.AppSetting.Name,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.AppSetting.JWTSecret))
+	// Fall back to HS256 when no asymmetric key is active
+	if activeKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.AppSetting.JWTSecret))
+	}
+
+	token := jwt.NewWithClaims(activeKey.method, claims)
+	token.Header["kid"] = activeKey.id
+	return token.SignedString(activeKey.private)
+}
+
+// verificationKey picks the key to verify a token with. Asymmetric tokens are
+// matched by kid; HS256 tokens are accepted while the migration window is open.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if activeKey != nil && !config.JWTSetting.AcceptHS256 {
+			return nil, errors.New("HS256 tokens are no longer accepted")
+		}
+		return []byte(config.AppSetting.JWTSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := signingKeys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if key.method.Alg() != token.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.private.Public(), nil
 }
 
 // ParseToken parses the JWT token
 func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppSetting.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{"EdDSA", "RS256", "HS256"}))
 
 	if err != nil {
 		return nil, err
@@ -55,6 +89,10 @@ func ParseToken(tokenString string) (*Claims, error) {
 // GenerateRefreshToken generates a refresh token
 func GenerateRefreshToken() (string, time.Time, error) {
 	expiresAt := time.Now().Add(time.Duration(config.AppSetting.RefreshTokenExpireTime) * time.Hour)
-	refreshToken := uuid.New().String()
-	return refreshToken, expiresAt, nil
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), expiresAt, nil
 }
@@ -1,17 +1,223 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/0xBoji/web3-edu-core/config"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword hashes a password
+// Password hashes are stored in the PHC string format, which records the
+// algorithm, its version and its parameters, e.g.
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+//
+// Legacy bcrypt hashes ($2a$, $2b$, $2y$) are verified as well.
+
+//go:embed common_passwords.txt
+var embeddedCommonPasswords []byte
+
+// commonPasswords are rejected by the password policy
+var commonPasswords = map[string]struct{}{}
+
+// argon2Params are the parameters of an argon2id hash
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// SetupPasswordPolicy loads the common password lists
+func SetupPasswordPolicy() error {
+	if err := loadCommonPasswords(bytes.NewReader(embeddedCommonPasswords)); err != nil {
+		return err
+	}
+
+	if path := config.PasswordSetting.CommonPasswordsFile; path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return loadCommonPasswords(f)
+	}
+
+	return nil
+}
+
+// HashPassword hashes a password with the configured algorithm
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	if passwordAlgorithm() == "bcrypt" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
+		return string(hashed), err
+	}
+
+	params := currentArgon2Params()
+	salt := make([]byte, params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.iterations, params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
 // CheckPasswordHash checks if the password matches the hash
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	return false
+}
+
+// NeedsRehash reports whether a hash was made with another algorithm or other
+// parameters than the configured ones
+func NeedsRehash(hash string) bool {
+	if passwordAlgorithm() == "bcrypt" {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != bcryptCost()
+	}
+
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params != currentArgon2Params()
+}
+
+// ValidatePassword checks that a password satisfies the password policy
+func ValidatePassword(password string) error {
+	minLength := config.PasswordSetting.MinLength
+	if minLength <= 0 {
+		minLength = 10
+	}
+	if len([]rune(password)) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain at least one letter and one digit")
+	}
+
+	if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+		return errors.New("password is too common, choose a less predictable one")
+	}
+
+	return nil
+}
+
+// decodeArgon2Hash parses an argon2id hash in the PHC string format
+func decodeArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.saltLength = uint32(len(salt))
+	params.keyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// currentArgon2Params returns the configured argon2id parameters, defaulting to
+// the second recommended option of RFC 9106
+func currentArgon2Params() argon2Params {
+	settings := config.PasswordSetting
+	return argon2Params{
+		memory:      uint32(positiveOr(settings.Argon2Memory, 64*1024)),
+		iterations:  uint32(positiveOr(settings.Argon2Iterations, 3)),
+		parallelism: uint8(positiveOr(settings.Argon2Parallelism, 4)),
+		saltLength:  uint32(positiveOr(settings.Argon2SaltLength, 16)),
+		keyLength:   uint32(positiveOr(settings.Argon2KeyLength, 32)),
+	}
+}
+
+// passwordAlgorithm returns the configured hashing algorithm
+func passwordAlgorithm() string {
+	if strings.EqualFold(config.PasswordSetting.Algorithm, "bcrypt") {
+		return "bcrypt"
+	}
+	return "argon2id"
+}
+
+// bcryptCost returns the configured bcrypt cost
+func bcryptCost() int {
+	return positiveOr(config.PasswordSetting.BcryptCost, bcrypt.DefaultCost)
+}
+
+// positiveOr returns value, or fallback when value is not set
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// loadCommonPasswords adds the passwords of a list, skipping blank lines and comments
+func loadCommonPasswords(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commonPasswords[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
 }
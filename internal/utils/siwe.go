@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// siweHeaderSuffix ends the first line of every EIP-4361 message
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// SIWEMessage represents a parsed EIP-4361 Sign-In with Ethereum message
+type SIWEMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSIWEMessage parses an EIP-4361 message
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("invalid SIWE message")
+	}
+
+	// Header and address
+	domain, ok := strings.CutSuffix(lines[0], siweHeaderSuffix)
+	if !ok || domain == "" {
+		return nil, errors.New("invalid SIWE message")
+	}
+	if _, rest, found := strings.Cut(domain, "://"); found {
+		domain = rest
+	}
+	if !IsHexAddress(lines[1]) || lines[2] != "" {
+		return nil, errors.New("invalid SIWE message")
+	}
+
+	msg := &SIWEMessage{Domain: domain, Address: lines[1]}
+
+	// Optional statement surrounded by blank lines
+	i := 3
+	if lines[i] != "" && !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		i++
+	}
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+
+	// Fields
+	var err error
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "URI: "):
+			msg.URI = strings.TrimPrefix(line, "URI: ")
+		case strings.HasPrefix(line, "Version: "):
+			msg.Version = strings.TrimPrefix(line, "Version: ")
+		case strings.HasPrefix(line, "Chain ID: "):
+			msg.ChainID, err = strconv.ParseInt(strings.TrimPrefix(line, "Chain ID: "), 10, 64)
+		case strings.HasPrefix(line, "Nonce: "):
+			msg.Nonce = strings.TrimPrefix(line, "Nonce: ")
+		case strings.HasPrefix(line, "Issued At: "):
+			msg.IssuedAt, err = time.Parse(time.RFC3339, strings.TrimPrefix(line, "Issued At: "))
+		case strings.HasPrefix(line, "Expiration Time: "):
+			msg.ExpirationTime, err = parseSIWETime(strings.TrimPrefix(line, "Expiration Time: "))
+		case strings.HasPrefix(line, "Not Before: "):
+			msg.NotBefore, err = parseSIWETime(strings.TrimPrefix(line, "Not Before: "))
+		case strings.HasPrefix(line, "Request ID: "):
+			msg.RequestID = strings.TrimPrefix(line, "Request ID: ")
+		case line == "Resources:":
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "- ") {
+				i++
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+		default:
+			return nil, errors.New("invalid SIWE message")
+		}
+		if err != nil {
+			return nil, errors.New("invalid SIWE message")
+		}
+	}
+
+	if msg.URI == "" || msg.Version == "" || msg.ChainID == 0 || len(msg.Nonce) < 8 || msg.IssuedAt.IsZero() {
+		return nil, errors.New("invalid SIWE message")
+	}
+
+	return msg, nil
+}
+
+// parseSIWETime parses an optional RFC 3339 timestamp
+func parseSIWETime(value string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
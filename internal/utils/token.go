@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/0xBoji/web3-edu-core/config"
+)
+
+// GenerateSignedToken generates a random URL-safe token signed with the app secret
+func GenerateSignedToken() (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(nonce)
+	return payload + "." + signPayload(payload), nil
+}
+
+// VerifySignedToken checks the signature of a token created by GenerateSignedToken
+func VerifySignedToken(token string) bool {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || payload == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signPayload(payload)))
+}
+
+// signPayload signs a payload with HMAC-SHA256
+func signPayload(payload string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppSetting.JWTSecret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of a token for storage
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
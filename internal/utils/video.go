@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var youtubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// ExtractYouTubeID extracts the video ID from a YouTube URL
+func ExtractYouTubeID(videoURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(videoURL))
+	if err != nil {
+		return "", errors.New("invalid video URL")
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	host = strings.TrimPrefix(host, "m.")
+
+	var id string
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "youtube-nocookie.com":
+		if u.Path == "/watch" {
+			id = u.Query().Get("v")
+			break
+		}
+		// Handle /embed/<id>, /shorts/<id>, /live/<id> and /v/<id>
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) == 2 {
+			switch parts[0] {
+			case "embed", "shorts", "live", "v":
+				id = parts[1]
+			}
+		}
+	}
+
+	if !youtubeIDPattern.MatchString(id) {
+		return "", errors.New("invalid YouTube video URL")
+	}
+
+	return id, nil
+}
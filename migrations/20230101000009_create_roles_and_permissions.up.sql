@@ -0,0 +1,66 @@
+CREATE TABLE roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE role_permissions (
+    role_id UUID REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id UUID REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE role_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    old_role VARCHAR(50) NOT NULL,
+    new_role VARCHAR(50) NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_role_changes_user_id ON role_changes(user_id);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Learner'),
+    ('instructor', 'Course instructor'),
+    ('admin', 'Platform administrator');
+
+INSERT INTO permissions (name, description) VALUES
+    ('course:write', 'Create, update and delete courses'),
+    ('lesson:write', 'Create, update and delete lessons'),
+    ('enrollment:read', 'View course rosters'),
+    ('category:write', 'Create, update and delete categories'),
+    ('user:read', 'View users'),
+    ('user:write', 'Update users'),
+    ('user:delete', 'Delete users'),
+    ('role:manage', 'Grant and revoke roles');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'instructor' AND p.name IN ('lesson:write', 'enrollment:read');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin';
+
+-- Roles used to be chosen at registration, so no existing admin or instructor
+-- role was granted by anyone. Every account is reset to learner and the reset
+-- is recorded; the first admin is then granted by hand (see the README).
+INSERT INTO role_changes (user_id, old_role, new_role, reason)
+SELECT id, role, 'user', 'reset by migration: role was self-assigned at registration'
+FROM users WHERE role <> 'user';
+
+UPDATE users SET role = 'user' WHERE role <> 'user';
+
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
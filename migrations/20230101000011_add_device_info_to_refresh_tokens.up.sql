@@ -0,0 +1,8 @@
+ALTER TABLE refresh_tokens ADD COLUMN user_agent TEXT;
+ALTER TABLE refresh_tokens ADD COLUMN ip_address VARCHAR(45);
+ALTER TABLE refresh_tokens ADD COLUMN device_label VARCHAR(255);
+ALTER TABLE refresh_tokens ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+UPDATE refresh_tokens SET last_used_at = created_at;
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,3 @@
+DELETE FROM users WHERE email IS NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS wallet_address;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
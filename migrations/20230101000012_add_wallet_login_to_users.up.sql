@@ -0,0 +1,3 @@
+-- Wallet-only accounts created through Sign-In with Ethereum have no email or password
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ADD COLUMN wallet_address VARCHAR(42) UNIQUE;
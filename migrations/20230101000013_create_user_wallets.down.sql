@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN wallet_address VARCHAR(42) UNIQUE;
+
+UPDATE users SET wallet_address = user_wallets.address
+FROM user_wallets
+WHERE user_wallets.user_id = users.id AND user_wallets.is_primary;
+
+DROP TABLE IF EXISTS user_wallets;
@@ -0,0 +1,19 @@
+CREATE TABLE user_wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL UNIQUE,
+    chain_id BIGINT NOT NULL,
+    label VARCHAR(100),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_wallets_user_id ON user_wallets(user_id);
+CREATE UNIQUE INDEX idx_user_wallets_primary ON user_wallets(user_id) WHERE is_primary;
+
+-- Move wallets of Sign-In with Ethereum accounts
+INSERT INTO user_wallets (user_id, address, chain_id, is_primary, verified_at, created_at)
+SELECT id, wallet_address, 1, TRUE, created_at, created_at FROM users WHERE wallet_address IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN wallet_address;
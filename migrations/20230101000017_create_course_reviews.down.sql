@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE name = 'review:moderate';
+
+DROP TABLE IF EXISTS course_reviews;
+
+ALTER TABLE courses
+    DROP COLUMN IF EXISTS rating_avg,
+    DROP COLUMN IF EXISTS rating_count;
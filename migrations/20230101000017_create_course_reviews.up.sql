@@ -0,0 +1,32 @@
+-- Aggregates of the visible reviews, kept up to date by the review service
+ALTER TABLE courses
+    ADD COLUMN rating_avg DECIMAL(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE course_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT,
+    reply TEXT,
+    replied_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    replied_at TIMESTAMP WITH TIME ZONE,
+    -- Hidden reviews are kept for moderators but not shown or counted
+    hidden_at TIMESTAMP WITH TIME ZONE,
+    hidden_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    hidden_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- One review per learner and course
+    UNIQUE (course_id, user_id)
+);
+
+CREATE INDEX idx_course_reviews_course_id ON course_reviews(course_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('review:moderate', 'Hide and restore course reviews');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'review:moderate';
@@ -0,0 +1,12 @@
+DELETE FROM role_permissions
+WHERE role_id = (SELECT id FROM roles WHERE name = 'instructor')
+  AND permission_id = (SELECT id FROM permissions WHERE name = 'course:write');
+
+DELETE FROM permissions WHERE name = 'course:publish';
+
+DROP TABLE IF EXISTS course_status_changes;
+
+ALTER TABLE courses
+    DROP CONSTRAINT IF EXISTS chk_courses_status,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,34 @@
+ALTER TABLE courses
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    ADD COLUMN published_at TIMESTAMP WITH TIME ZONE;
+
+-- Courses created before the publishing workflow stay in the catalog
+UPDATE courses SET status = 'published', published_at = created_at;
+
+ALTER TABLE courses ADD CONSTRAINT chk_courses_status CHECK (status IN ('draft', 'review', 'published', 'archived'));
+
+CREATE INDEX idx_courses_status ON courses(status);
+
+CREATE TABLE course_status_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    course_id UUID REFERENCES courses(id) ON DELETE CASCADE,
+    old_status VARCHAR(20) NOT NULL,
+    new_status VARCHAR(20) NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    comment TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_course_status_changes_course_id ON course_status_changes(course_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('course:publish', 'Approve, reject and restore courses');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'course:publish';
+
+-- Instructors write their own courses and submit them for review
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'instructor' AND p.name = 'course:write';
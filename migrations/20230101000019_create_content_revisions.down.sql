@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS content_revisions;
+
+DROP FUNCTION IF EXISTS reject_content_revision_change();
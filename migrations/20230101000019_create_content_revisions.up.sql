@@ -0,0 +1,49 @@
+CREATE TABLE content_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('course', 'lesson')),
+    entity_id UUID NOT NULL,
+    version INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    restored_version INT,
+    snapshot JSONB NOT NULL,
+    -- No foreign key: revisions are kept when their author is deleted, and an
+    -- ON DELETE SET NULL would be an update the trigger below rejects
+    author_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (entity_type, entity_id, version)
+);
+
+-- Revisions are immutable
+CREATE FUNCTION reject_content_revision_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'content revisions cannot be modified or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_content_revisions_immutable
+    BEFORE UPDATE OR DELETE ON content_revisions
+    FOR EACH ROW EXECUTE FUNCTION reject_content_revision_change();
+
+-- The current content of existing courses and lessons becomes their first revision
+INSERT INTO content_revisions (entity_type, entity_id, version, action, snapshot, created_at)
+SELECT 'course', id, 1, 'create', jsonb_build_object(
+    'title', title,
+    'description', COALESCE(description, ''),
+    'thumbnail', COALESCE(thumbnail, ''),
+    'price', COALESCE(price, 0),
+    'level', COALESCE(level, ''),
+    'duration', COALESCE(duration, 0),
+    'category', COALESCE(category, '')
+), updated_at
+FROM courses;
+
+INSERT INTO content_revisions (entity_type, entity_id, version, action, snapshot, created_at)
+SELECT 'lesson', id, 1, 'create', jsonb_build_object(
+    'title', title,
+    'description', COALESCE(description, ''),
+    'video_url', video_url,
+    'video_id', video_id,
+    'duration', COALESCE(duration, 0),
+    'order_number', order_number
+), updated_at
+FROM lessons;
@@ -0,0 +1,26 @@
+DELETE FROM permissions WHERE name = 'trash:manage';
+
+CREATE OR REPLACE FUNCTION reject_content_revision_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'content revisions cannot be modified or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Soft-deleted rows are removed for good
+DELETE FROM lessons WHERE deleted_at IS NOT NULL;
+DELETE FROM courses WHERE deleted_at IS NOT NULL;
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+DELETE FROM users WHERE deleted_at IS NOT NULL AND id NOT IN (SELECT instructor_id FROM courses WHERE instructor_id IS NOT NULL);
+
+DROP INDEX IF EXISTS idx_categories_slug;
+DROP INDEX IF EXISTS idx_categories_name;
+ALTER TABLE categories ADD CONSTRAINT categories_slug_key UNIQUE (slug);
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE lessons DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE courses DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,37 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE courses ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE lessons ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at);
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at);
+CREATE INDEX idx_courses_deleted_at ON courses(deleted_at);
+CREATE INDEX idx_lessons_deleted_at ON lessons(deleted_at);
+
+-- Deleted users and categories no longer reserve their email address, name or slug
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX idx_users_email ON users(email) WHERE deleted_at IS NULL;
+
+ALTER TABLE categories DROP CONSTRAINT categories_name_key;
+ALTER TABLE categories DROP CONSTRAINT categories_slug_key;
+CREATE UNIQUE INDEX idx_categories_name ON categories(name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug) WHERE deleted_at IS NULL;
+
+-- The trash purge deletes the revisions of the courses and lessons it removes.
+-- It marks its transaction with app.purging_content_revisions, which is the only
+-- way past the trigger keeping revisions immutable.
+CREATE OR REPLACE FUNCTION reject_content_revision_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('app.purging_content_revisions', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'content revisions cannot be modified or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO permissions (name, description) VALUES
+    ('trash:manage', 'List and restore deleted courses, lessons, users and categories');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'trash:manage';